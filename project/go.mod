@@ -4,7 +4,10 @@ go 1.25.4
 
 require github.com/nitram509/gofritz v0.2.1
 
-require github.com/joho/godotenv v1.5.1
+require (
+	github.com/joho/godotenv v1.5.1
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/google/gopacket v1.1.19 // indirect
@@ -22,6 +22,12 @@ type TestRecord struct {
 	Config     string    `json:"config"`      // JSON string of test config
 	Data       string    `json:"data"`        // JSON string of data points
 	Summary    string    `json:"summary"`     // JSON string of test summary stats
+	Hooks      string    `json:"hooks,omitempty"`       // JSON string of hook results
+	Throughput string    `json:"throughput,omitempty"`  // JSON string of throughput samples
+	CampaignID string    `json:"campaign_id,omitempty"` // Shared by the runs of a test matrix
+	GroupID    string    `json:"group_id,omitempty"`    // Shared by the repetitions of a config
+	Status     string    `json:"status"`                // Run state, see TestRunning etc.
+	Error      string    `json:"error,omitempty"`       // Why an aborted or crashed test ended early
 	CreatedAt  time.Time `json:"created_at"`
 }
 
@@ -33,18 +39,83 @@ type TestSummary struct {
 	MinPowerMW           float64            `json:"min_power_mw"`
 	AverageThroughputMbps float64           `json:"average_throughput_mbps"`
 	MaxThroughputMbps    float64            `json:"max_throughput_mbps"`
+	ThroughputSampleCount int               `json:"throughput_sample_count,omitempty"` // Samples taken at the throughput interval
 	TotalDataPoints      int                `json:"total_data_points"`
+	ExcludedDataPoints   int                `json:"excluded_data_points"` // Stale or missing readings, not used in statistics
+	RepeatedDataPoints   int                `json:"repeated_data_points"` // Readings not refreshed by the meter, not used in power statistics
+	SampleQuality        map[string]int     `json:"sample_quality,omitempty"` // Data point count by quality flag
 	PhaseStats           map[string]PhaseStats `json:"phase_stats"`
+	Steps                []string           `json:"steps,omitempty"` // Test plan step labels in order, statistics are in PhaseStats
+	MeterStats           map[string]MeterStats `json:"meter_stats,omitempty"` // Additional meters, keyed by name
+
+	// Delay of the power reading behind load changes, from cross-correlating power and throughput
+	MeterLagSeconds     *float64 `json:"meter_lag_seconds,omitempty"`
+	MeterLagCorrelation float64  `json:"meter_lag_correlation,omitempty"`
+	MeterLagEvents      int      `json:"meter_lag_events,omitempty"` // Load changes used for the estimate
+
+	AbortReason string `json:"abort_reason,omitempty"` // Safety rule that stopped the test
+
+	Boot *BootStats `json:"boot,omitempty"` // Power-cycle boot tests only
+	Idle *IdleStats `json:"idle,omitempty"` // Idle characterizations only
+}
+
+// IdleStats contains the steady-state statistics of an idle characterization
+type IdleStats struct {
+	Stabilized    bool     `json:"stabilized"` // Stability criterion held before the settle limit
+	SettleSeconds float64  `json:"settle_seconds"`
+	SettleReason  string   `json:"settle_reason"`
+	MeanPowerMW   float64  `json:"mean_power_mw"`
+	NoiseFloorMW  float64  `json:"noise_floor_mw"` // Standard deviation of the steady-state samples
+	CI95LowMW     *float64 `json:"ci95_low_mw,omitempty"`
+	CI95HighMW    *float64 `json:"ci95_high_mw,omitempty"`
+	SampleCount   int      `json:"sample_count"`
+}
+
+// BootStats contains the boot cost of a power-cycle test
+type BootStats struct {
+	Ready           bool    `json:"ready"` // Target became reachable within the timeout
+	BootSeconds     float64 `json:"boot_seconds"`
+	PeakPowerMW     float64 `json:"peak_power_mw"`      // Inrush peak between switch-on and ready
+	EnergyToReadyWh float64 `json:"energy_to_ready_wh"` // Energy between switch-on and ready
+	OffPowerMW      float64 `json:"off_power_mw"`       // Average power with the outlet off
+}
+
+// MeterStats contains overall statistics for an additional meter
+type MeterStats struct {
+	AveragePowerMW float64 `json:"average_power_mw"`
+	MaxPowerMW     float64 `json:"max_power_mw"`
+	MinPowerMW     float64 `json:"min_power_mw"`
+}
+
+// MeterPhaseStats contains statistics for an additional meter during a test phase
+type MeterPhaseStats struct {
+	AveragePowerMW     float64 `json:"average_power_mw"`
+	PowerStdDevMW      float64 `json:"power_std_dev_mw"`
+	EnergyIntegratedWh float64 `json:"energy_integrated_wh"`
+	DataPointCount     int     `json:"data_point_count"`
 }
 
 // PhaseStats contains statistics for a specific test phase
 type PhaseStats struct {
-	DurationSeconds       float64 `json:"duration_seconds"`
-	AveragePowerMW        float64 `json:"average_power_mw"`
-	PowerStdDevMW         float64 `json:"power_std_dev_mw"`
-	AverageThroughputMbps float64 `json:"average_throughput_mbps"`
-	ThroughputStdDevMbps  float64 `json:"throughput_std_dev_mbps"`
-	DataPointCount        int     `json:"data_point_count"`
+	DurationSeconds        float64 `json:"duration_seconds"`
+	AveragePowerMW         float64 `json:"average_power_mw"`
+	PowerStdDevMW          float64 `json:"power_std_dev_mw"`
+	AverageThroughputMbps  float64 `json:"average_throughput_mbps"`
+	ThroughputStdDevMbps   float64 `json:"throughput_std_dev_mbps"`
+	DataPointCount         int     `json:"data_point_count"`
+	ThroughputSampleCount  int     `json:"throughput_sample_count,omitempty"` // Throughput statistics are from these samples if set
+	ExcludedDataPointCount int     `json:"excluded_data_point_count,omitempty"` // Stale or missing readings
+	RepeatedDataPointCount int     `json:"repeated_data_point_count,omitempty"` // Readings not refreshed by the meter
+
+	// Energy consumed during the phase, from the meter's counter and from integrating PowerMW
+	EnergyCounterWh    *float64 `json:"energy_counter_wh,omitempty"`
+	EnergyIntegratedWh float64  `json:"energy_integrated_wh"`
+	EnergyMismatch     bool     `json:"energy_mismatch,omitempty"` // Counter and integral disagree beyond tolerance
+
+	MeterStats map[string]MeterPhaseStats `json:"meter_stats,omitempty"` // Additional meters, keyed by name
+
+	// Average power with the power series shifted back by the estimated meter lag
+	LagCorrectedAveragePowerMW *float64 `json:"lag_corrected_average_power_mw,omitempty"`
 }
 
 // New creates a new database connection and initializes schema
@@ -89,17 +160,93 @@ func (d *Database) initSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_tests_created_at ON tests(created_at);
 	`
 
-	_, err := d.db.Exec(schema)
+	if _, err := d.db.Exec(schema); err != nil {
+		return err
+	}
+	if err := d.initQueueSchema(); err != nil {
+		return err
+	}
+	if err := d.initRecordingSchema(); err != nil {
+		return err
+	}
+	return d.migrate()
+}
+
+// migrate adds columns introduced after the initial schema to existing databases
+func (d *Database) migrate() error {
+	rows, err := d.db.Query(`PRAGMA table_info(tests)`)
+	if err != nil {
+		return fmt.Errorf("failed to read table info: %w", err)
+	}
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan table info: %w", err)
+		}
+		columns[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, column := range []string{"campaign_id", "group_id"} {
+		if !columns[column] {
+			if _, err := d.db.Exec(`ALTER TABLE tests ADD COLUMN ` + column + ` TEXT NOT NULL DEFAULT ''`); err != nil {
+				return fmt.Errorf("failed to add %s column: %w", column, err)
+			}
+		}
+		if _, err := d.db.Exec(`CREATE INDEX IF NOT EXISTS idx_tests_` + column + ` ON tests(` + column + `)`); err != nil {
+			return err
+		}
+	}
+
+	// Tests saved before incremental persistence only ever ran to completion
+	if !columns["status"] {
+		if _, err := d.db.Exec(`ALTER TABLE tests ADD COLUMN status TEXT NOT NULL DEFAULT '` + TestCompleted + `'`); err != nil {
+			return fmt.Errorf("failed to add status column: %w", err)
+		}
+	}
+	if !columns["hooks"] {
+		if _, err := d.db.Exec(`ALTER TABLE tests ADD COLUMN hooks TEXT NOT NULL DEFAULT ''`); err != nil {
+			return fmt.Errorf("failed to add hooks column: %w", err)
+		}
+	}
+	if !columns["throughput"] {
+		if _, err := d.db.Exec(`ALTER TABLE tests ADD COLUMN throughput TEXT NOT NULL DEFAULT ''`); err != nil {
+			return fmt.Errorf("failed to add throughput column: %w", err)
+		}
+	}
+	if !columns["error"] {
+		if _, err := d.db.Exec(`ALTER TABLE tests ADD COLUMN error TEXT NOT NULL DEFAULT ''`); err != nil {
+			return fmt.Errorf("failed to add error column: %w", err)
+		}
+	}
+	// Unix time of the last sign of life of a running test's process
+	if !columns["heartbeat_at"] {
+		if _, err := d.db.Exec(`ALTER TABLE tests ADD COLUMN heartbeat_at INTEGER NOT NULL DEFAULT 0`); err != nil {
+			return fmt.Errorf("failed to add heartbeat_at column: %w", err)
+		}
+	}
+	_, err = d.db.Exec(`CREATE INDEX IF NOT EXISTS idx_tests_status ON tests(status)`)
 	return err
 }
 
 // SaveTest saves a test record to the database
 func (d *Database) SaveTest(record *TestRecord) (int64, error) {
 	query := `
-	INSERT INTO tests (test_name, device_name, timestamp, config, data, summary, created_at)
-	VALUES (?, ?, ?, ?, ?, ?, ?)
+	INSERT INTO tests (test_name, device_name, timestamp, config, data, summary, hooks, throughput, campaign_id, group_id, status, error, created_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
+	status := record.Status
+	if status == "" {
+		status = TestCompleted
+	}
 	result, err := d.db.Exec(query,
 		record.TestName,
 		record.DeviceName,
@@ -107,6 +254,12 @@ func (d *Database) SaveTest(record *TestRecord) (int64, error) {
 		record.Config,
 		record.Data,
 		record.Summary,
+		record.Hooks,
+		record.Throughput,
+		record.CampaignID,
+		record.GroupID,
+		status,
+		record.Error,
 		time.Now(),
 	)
 	if err != nil {
@@ -124,7 +277,7 @@ func (d *Database) SaveTest(record *TestRecord) (int64, error) {
 // GetTest retrieves a test by ID
 func (d *Database) GetTest(id int64) (*TestRecord, error) {
 	query := `
-	SELECT id, test_name, device_name, timestamp, config, data, summary, created_at
+	SELECT id, test_name, device_name, timestamp, config, data, summary, hooks, throughput, campaign_id, group_id, status, error, created_at
 	FROM tests
 	WHERE id = ?
 	`
@@ -138,19 +291,35 @@ func (d *Database) GetTest(id int64) (*TestRecord, error) {
 		&record.Config,
 		&record.Data,
 		&record.Summary,
+		&record.Hooks,
+		&record.Throughput,
+		&record.CampaignID,
+		&record.GroupID,
+		&record.Status,
+		&record.Error,
 		&record.CreatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get test: %w", err)
 	}
 
+	// The data points and throughput samples of a running test are only in the streamed rows
+	if record.Status == TestRunning {
+		if record.Data, err = d.streamedData("test_data_points", id); err != nil {
+			return nil, err
+		}
+		if record.Throughput, err = d.streamedData("test_throughput_samples", id); err != nil {
+			return nil, err
+		}
+	}
+
 	return &record, nil
 }
 
 // ListTests retrieves all tests, ordered by timestamp descending
 func (d *Database) ListTests() ([]*TestRecord, error) {
 	query := `
-	SELECT id, test_name, device_name, timestamp, config, data, summary, created_at
+	SELECT id, test_name, device_name, timestamp, config, data, summary, hooks, throughput, campaign_id, group_id, status, error, created_at
 	FROM tests
 	ORDER BY timestamp DESC
 	`
@@ -172,6 +341,12 @@ func (d *Database) ListTests() ([]*TestRecord, error) {
 			&record.Config,
 			&record.Data,
 			&record.Summary,
+			&record.Hooks,
+			&record.Throughput,
+			&record.CampaignID,
+			&record.GroupID,
+			&record.Status,
+			&record.Error,
 			&record.CreatedAt,
 		)
 		if err != nil {
@@ -186,7 +361,7 @@ func (d *Database) ListTests() ([]*TestRecord, error) {
 // ListTestsByDevice retrieves all tests for a specific device
 func (d *Database) ListTestsByDevice(deviceName string) ([]*TestRecord, error) {
 	query := `
-	SELECT id, test_name, device_name, timestamp, config, data, summary, created_at
+	SELECT id, test_name, device_name, timestamp, config, data, summary, hooks, throughput, campaign_id, group_id, status, error, created_at
 	FROM tests
 	WHERE device_name = ?
 	ORDER BY timestamp DESC
@@ -209,6 +384,98 @@ func (d *Database) ListTestsByDevice(deviceName string) ([]*TestRecord, error) {
 			&record.Config,
 			&record.Data,
 			&record.Summary,
+			&record.Hooks,
+			&record.Throughput,
+			&record.CampaignID,
+			&record.GroupID,
+			&record.Status,
+			&record.Error,
+			&record.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan test: %w", err)
+		}
+		tests = append(tests, &record)
+	}
+
+	return tests, rows.Err()
+}
+
+// ListTestsByCampaign retrieves the runs of a test matrix campaign in execution order
+func (d *Database) ListTestsByCampaign(campaignID string) ([]*TestRecord, error) {
+	query := `
+	SELECT id, test_name, device_name, timestamp, config, data, summary, hooks, throughput, campaign_id, group_id, status, error, created_at
+	FROM tests
+	WHERE campaign_id = ?
+	ORDER BY timestamp ASC
+	`
+
+	rows, err := d.db.Query(query, campaignID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tests by campaign: %w", err)
+	}
+	defer rows.Close()
+
+	var tests []*TestRecord
+	for rows.Next() {
+		var record TestRecord
+		err := rows.Scan(
+			&record.ID,
+			&record.TestName,
+			&record.DeviceName,
+			&record.Timestamp,
+			&record.Config,
+			&record.Data,
+			&record.Summary,
+			&record.Hooks,
+			&record.Throughput,
+			&record.CampaignID,
+			&record.GroupID,
+			&record.Status,
+			&record.Error,
+			&record.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan test: %w", err)
+		}
+		tests = append(tests, &record)
+	}
+
+	return tests, rows.Err()
+}
+
+// ListTestsByGroup retrieves the repetitions of a config in execution order
+func (d *Database) ListTestsByGroup(groupID string) ([]*TestRecord, error) {
+	query := `
+	SELECT id, test_name, device_name, timestamp, config, data, summary, hooks, throughput, campaign_id, group_id, status, error, created_at
+	FROM tests
+	WHERE group_id = ?
+	ORDER BY timestamp ASC
+	`
+
+	rows, err := d.db.Query(query, groupID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tests by group: %w", err)
+	}
+	defer rows.Close()
+
+	var tests []*TestRecord
+	for rows.Next() {
+		var record TestRecord
+		err := rows.Scan(
+			&record.ID,
+			&record.TestName,
+			&record.DeviceName,
+			&record.Timestamp,
+			&record.Config,
+			&record.Data,
+			&record.Summary,
+			&record.Hooks,
+			&record.Throughput,
+			&record.CampaignID,
+			&record.GroupID,
+			&record.Status,
+			&record.Error,
 			&record.CreatedAt,
 		)
 		if err != nil {
@@ -227,6 +494,12 @@ func (d *Database) DeleteTest(id int64) error {
 	if err != nil {
 		return fmt.Errorf("failed to delete test: %w", err)
 	}
+	if _, err := d.db.Exec(`DELETE FROM test_data_points WHERE test_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete data points: %w", err)
+	}
+	if _, err := d.db.Exec(`DELETE FROM test_throughput_samples WHERE test_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete throughput samples: %w", err)
+	}
 	return nil
 }
 
@@ -254,7 +527,7 @@ func (d *Database) Close() error {
 // SearchTests searches tests by name or device name
 func (d *Database) SearchTests(searchTerm string) ([]*TestRecord, error) {
 	query := `
-	SELECT id, test_name, device_name, timestamp, config, data, summary, created_at
+	SELECT id, test_name, device_name, timestamp, config, data, summary, hooks, throughput, campaign_id, group_id, status, error, created_at
 	FROM tests
 	WHERE test_name LIKE ? OR device_name LIKE ?
 	ORDER BY timestamp DESC
@@ -278,6 +551,12 @@ func (d *Database) SearchTests(searchTerm string) ([]*TestRecord, error) {
 			&record.Config,
 			&record.Data,
 			&record.Summary,
+			&record.Hooks,
+			&record.Throughput,
+			&record.CampaignID,
+			&record.GroupID,
+			&record.Status,
+			&record.Error,
 			&record.CreatedAt,
 		)
 		if err != nil {
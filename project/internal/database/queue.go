@@ -0,0 +1,264 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Queue entry states
+const (
+	QueueQueued    = "queued"
+	QueueRunning   = "running"
+	QueueDone      = "done"
+	QueueFailed    = "failed"
+	QueueCancelled = "cancelled"
+)
+
+// QueueEntry is a test waiting in the persistent test queue
+type QueueEntry struct {
+	ID         int64      `json:"id"`
+	Position   int64      `json:"position"` // Execution order, lowest first
+	TestName   string     `json:"test_name"`
+	Form       string     `json:"form"`               // URL-encoded start form
+	StartAt    *time.Time `json:"start_at,omitempty"` // Not started before this time
+	Cron       string     `json:"cron,omitempty"`     // Recurring schedule, requeued after each run
+	Status     string     `json:"status"`
+	Error      string     `json:"error,omitempty"`
+	RunCount   int        `json:"run_count"`
+	CreatedAt  time.Time  `json:"created_at"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// initQueueSchema creates the test queue table if it doesn't exist
+func (d *Database) initQueueSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS queue (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		position INTEGER NOT NULL,
+		test_name TEXT NOT NULL,
+		form TEXT NOT NULL,
+		start_at DATETIME,
+		cron TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		run_count INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		started_at DATETIME,
+		finished_at DATETIME
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_queue_status_position ON queue(status, position);
+	`
+	_, err := d.db.Exec(schema)
+	return err
+}
+
+// EnqueueTest appends an entry to the end of the queue
+func (d *Database) EnqueueTest(entry *QueueEntry) (int64, error) {
+	query := `
+	INSERT INTO queue (position, test_name, form, start_at, cron, status, created_at)
+	VALUES ((SELECT COALESCE(MAX(position), 0) + 1 FROM queue), ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := d.db.Exec(query,
+		entry.TestName,
+		entry.Form,
+		nullTime(entry.StartAt),
+		entry.Cron,
+		QueueQueued,
+		time.Now(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue test: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	return id, nil
+}
+
+// GetQueueEntry retrieves a queue entry by ID
+func (d *Database) GetQueueEntry(id int64) (*QueueEntry, error) {
+	query := `
+	SELECT id, position, test_name, form, start_at, cron, status, error, run_count, created_at, started_at, finished_at
+	FROM queue
+	WHERE id = ?
+	`
+
+	entry, err := scanQueueEntry(d.db.QueryRow(query, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get queue entry: %w", err)
+	}
+	return entry, nil
+}
+
+// ListQueue retrieves all queue entries in execution order
+func (d *Database) ListQueue() ([]*QueueEntry, error) {
+	query := `
+	SELECT id, position, test_name, form, start_at, cron, status, error, run_count, created_at, started_at, finished_at
+	FROM queue
+	ORDER BY position ASC
+	`
+
+	rows, err := d.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list queue: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*QueueEntry
+	for rows.Next() {
+		entry, err := scanQueueEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan queue entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// UpdateQueueEntry stores the state of a queue entry
+func (d *Database) UpdateQueueEntry(entry *QueueEntry) error {
+	query := `
+	UPDATE queue SET start_at = ?, status = ?, error = ?, run_count = ?, started_at = ?, finished_at = ?
+	WHERE id = ?
+	`
+
+	_, err := d.db.Exec(query,
+		nullTime(entry.StartAt),
+		entry.Status,
+		entry.Error,
+		entry.RunCount,
+		nullTime(entry.StartedAt),
+		nullTime(entry.FinishedAt),
+		entry.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update queue entry: %w", err)
+	}
+	return nil
+}
+
+// CancelQueueEntry cancels a queued or running entry. It returns false if the
+// entry has already finished.
+func (d *Database) CancelQueueEntry(id int64) (bool, error) {
+	query := `UPDATE queue SET status = ?, finished_at = ? WHERE id = ? AND status IN (?, ?)`
+	result, err := d.db.Exec(query, QueueCancelled, time.Now(), id, QueueQueued, QueueRunning)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel queue entry: %w", err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// MoveQueueEntry moves a queued entry to the given 1-based position among the
+// queued entries. Running and finished entries keep their positions.
+func (d *Database) MoveQueueEntry(id int64, position int) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id, position FROM queue WHERE status = ? ORDER BY position ASC`, QueueQueued)
+	if err != nil {
+		return fmt.Errorf("failed to list queue: %w", err)
+	}
+	var ids, positions []int64
+	index := -1
+	for rows.Next() {
+		var entryID, entryPosition int64
+		if err := rows.Scan(&entryID, &entryPosition); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan queue entry: %w", err)
+		}
+		if entryID == id {
+			index = len(ids)
+		}
+		ids = append(ids, entryID)
+		positions = append(positions, entryPosition)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if index < 0 {
+		return fmt.Errorf("queue entry %d is not queued", id)
+	}
+
+	// Reorder the IDs and hand out the existing positions in the new order
+	target := min(max(position, 1), len(ids)) - 1
+	ids = append(ids[:index], ids[index+1:]...)
+	ids = append(ids[:target], append([]int64{id}, ids[target:]...)...)
+	for i, entryID := range ids {
+		if _, err := tx.Exec(`UPDATE queue SET position = ? WHERE id = ?`, positions[i], entryID); err != nil {
+			return fmt.Errorf("failed to move queue entry: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// RequeueInterrupted puts entries left running by a previous process back into
+// the queue and returns how many there were
+func (d *Database) RequeueInterrupted() (int64, error) {
+	result, err := d.db.Exec(`UPDATE queue SET status = ?, started_at = NULL WHERE status = ?`, QueueQueued, QueueRunning)
+	if err != nil {
+		return 0, fmt.Errorf("failed to requeue interrupted entries: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// rowScanner is implemented by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanQueueEntry(row rowScanner) (*QueueEntry, error) {
+	var entry QueueEntry
+	var startAt, startedAt, finishedAt sql.NullTime
+	err := row.Scan(
+		&entry.ID,
+		&entry.Position,
+		&entry.TestName,
+		&entry.Form,
+		&startAt,
+		&entry.Cron,
+		&entry.Status,
+		&entry.Error,
+		&entry.RunCount,
+		&entry.CreatedAt,
+		&startedAt,
+		&finishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	entry.StartAt = timePtr(startAt)
+	entry.StartedAt = timePtr(startedAt)
+	entry.FinishedAt = timePtr(finishedAt)
+	return &entry, nil
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,183 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Test run states
+const (
+	TestRunning   = "running"
+	TestCompleted = "completed"
+	TestAborted   = "aborted" // Stopped or failed, the data points up to then are kept
+	TestCrashed   = "crashed" // Server stopped while the test was running, finalized on the next start
+)
+
+// initRecordingSchema creates the tables holding the data points and throughput
+// samples of running tests
+func (d *Database) initRecordingSchema() error {
+	schema := `
+	CREATE TABLE IF NOT EXISTS test_data_points (
+		test_id INTEGER NOT NULL,
+		seq INTEGER NOT NULL,
+		data TEXT NOT NULL,
+		PRIMARY KEY (test_id, seq)
+	);
+
+	CREATE TABLE IF NOT EXISTS test_throughput_samples (
+		test_id INTEGER NOT NULL,
+		seq INTEGER NOT NULL,
+		data TEXT NOT NULL,
+		PRIMARY KEY (test_id, seq)
+	);
+	`
+	_, err := d.db.Exec(schema)
+	return err
+}
+
+// BeginTest saves a test that has just started. Its data points and throughput
+// samples are added with AppendDataPoint and AppendThroughputSamples until
+// FinishTest stores the final record.
+func (d *Database) BeginTest(record *TestRecord) (int64, error) {
+	query := `
+	INSERT INTO tests (test_name, device_name, timestamp, config, data, summary, campaign_id, group_id, status, error, heartbeat_at, created_at)
+	VALUES (?, ?, ?, ?, '[]', '', ?, ?, ?, '', ?, ?)
+	`
+
+	result, err := d.db.Exec(query,
+		record.TestName,
+		record.DeviceName,
+		record.Timestamp,
+		record.Config,
+		record.CampaignID,
+		record.GroupID,
+		TestRunning,
+		time.Now().Unix(),
+		time.Now(),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin test: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	return id, nil
+}
+
+// Heartbeat marks a running test as still owned by a live process
+func (d *Database) Heartbeat(testID int64) error {
+	query := `UPDATE tests SET heartbeat_at = ? WHERE id = ? AND status = ?`
+	if _, err := d.db.Exec(query, time.Now().Unix(), testID, TestRunning); err != nil {
+		return fmt.Errorf("failed to update heartbeat: %w", err)
+	}
+	return nil
+}
+
+// AppendDataPoint stores the JSON data point with the 1-based sequence number seq
+func (d *Database) AppendDataPoint(testID int64, seq int, data string) error {
+	query := `INSERT INTO test_data_points (test_id, seq, data) VALUES (?, ?, ?)`
+	if _, err := d.db.Exec(query, testID, seq, data); err != nil {
+		return fmt.Errorf("failed to append data point: %w", err)
+	}
+	return nil
+}
+
+// AppendThroughputSamples stores JSON throughput samples in one transaction,
+// the first with the 1-based sequence number firstSeq
+func (d *Database) AppendThroughputSamples(testID int64, firstSeq int, samples []string) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO test_throughput_samples (test_id, seq, data) VALUES (?, ?, ?)`
+	for i, data := range samples {
+		if _, err := tx.Exec(query, testID, firstSeq+i, data); err != nil {
+			return fmt.Errorf("failed to append throughput sample: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// FinishTest stores the data, throughput samples, summary, hook results,
+// status and error of a test begun with BeginTest and drops its streamed rows
+func (d *Database) FinishTest(record *TestRecord) error {
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE tests SET data = ?, throughput = ?, summary = ?, hooks = ?, status = ?, error = ? WHERE id = ?`
+	if _, err := tx.Exec(query, record.Data, record.Throughput, record.Summary, record.Hooks, record.Status, record.Error, record.ID); err != nil {
+		return fmt.Errorf("failed to finish test: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM test_data_points WHERE test_id = ?`, record.ID); err != nil {
+		return fmt.Errorf("failed to delete data points: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM test_throughput_samples WHERE test_id = ?`, record.ID); err != nil {
+		return fmt.Errorf("failed to delete throughput samples: %w", err)
+	}
+	return tx.Commit()
+}
+
+// ListAbandonedTests retrieves the running tests without a heartbeat since
+// staleBefore, oldest first. Their process stopped without finishing them.
+func (d *Database) ListAbandonedTests(staleBefore time.Time) ([]*TestRecord, error) {
+	query := `SELECT id FROM tests WHERE status = ? AND heartbeat_at < ? ORDER BY timestamp ASC`
+	rows, err := d.db.Query(query, TestRunning, staleBefore.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list abandoned tests: %w", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan test: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// GetTest assembles the streamed data points of running tests
+	tests := make([]*TestRecord, 0, len(ids))
+	for _, id := range ids {
+		record, err := d.GetTest(id)
+		if err != nil {
+			return nil, err
+		}
+		tests = append(tests, record)
+	}
+	return tests, nil
+}
+
+// streamedData returns the rows of a test streamed into table as a JSON array
+func (d *Database) streamedData(table string, testID int64) (string, error) {
+	rows, err := d.db.Query(`SELECT data FROM `+table+` WHERE test_id = ? ORDER BY seq`, testID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var points []string
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return "", fmt.Errorf("failed to scan %s row: %w", table, err)
+		}
+		points = append(points, data)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return "[" + strings.Join(points, ",") + "]", nil
+}
@@ -0,0 +1,365 @@
+package fritzbox
+
+import (
+	"crypto/md5"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf16"
+)
+
+// invalidSID is returned by login_sid.lua when no session is established
+const invalidSID = "0000000000000000"
+
+// AHAPowerMeter talks to the FritzBox AHA-HTTP interface (webservices/homeautoswitch.lua)
+// instead of TR-064. Besides power it also exposes voltage, the energy counter
+// and the device statistics recorded by the FritzBox.
+type AHAPowerMeter struct {
+	BaseURL  string // e.g. http://fritz.box (no TR-064 port)
+	Username string
+	Password string
+	AIN      string
+	Client   *http.Client
+
+	mu  sync.Mutex
+	sid string
+}
+
+// AHADeviceInfo contains the values of a getdeviceinfos response converted to SI-ish units
+type AHADeviceInfo struct {
+	Name      string
+	Present   bool
+	SwitchOn  bool
+	PowerMW   float64 // Current power in mW
+	VoltageMV float64 // Current voltage in mV
+	EnergyWh  float64 // Energy counter since last reset in Wh
+}
+
+// AHAStatsSeries is one statistics series (newest value first) as reported by getbasicdevicestats
+type AHAStatsSeries struct {
+	Grid     time.Duration // Spacing between values
+	DataTime time.Time     // Timestamp of the newest value (zero if not reported)
+	Values   []float64     // Missing values ("-") are skipped
+}
+
+// AHADeviceStats contains the statistics history of a device
+type AHADeviceStats struct {
+	VoltageMV []AHAStatsSeries
+	PowerMW   []AHAStatsSeries
+	EnergyWh  []AHAStatsSeries
+}
+
+func NewAHAPowerMeter(baseURL, username, password, ain string) *AHAPowerMeter {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+
+	fmt.Printf("Initializing AHAPowerMeter with URL: %s, User: %s, AIN: %s\n", baseURL, username, ain)
+
+	return &AHAPowerMeter{
+		BaseURL:  baseURL,
+		Username: username,
+		Password: password,
+		AIN:      ain,
+		Client:   &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (a *AHAPowerMeter) GetCurrentPower() (float64, error) {
+	info, err := a.GetDeviceInfo()
+	if err != nil {
+		return 0, err
+	}
+	return info.PowerMW, nil
+}
+
+// GetSample returns power, voltage and the energy counter in a single request
+func (a *AHAPowerMeter) GetSample() (Sample, error) {
+	info, err := a.GetDeviceInfo()
+	if err != nil {
+		return Sample{}, err
+	}
+	return Sample{
+		PowerMW:  info.PowerMW,
+		VoltageV: floatPtr(info.VoltageMV / 1000),
+		EnergyWh: floatPtr(info.EnergyWh),
+	}, nil
+}
+
+func (a *AHAPowerMeter) TestConnection() error {
+	_, err := a.GetDeviceInfo()
+	return err
+}
+
+// SetSwitch switches the outlet with setswitchon/setswitchoff
+func (a *AHAPowerMeter) SetSwitch(on bool) error {
+	cmd := "setswitchoff"
+	if on {
+		cmd = "setswitchon"
+	}
+	_, err := a.command(cmd, nil)
+	return err
+}
+
+// GetDeviceInfo returns power, voltage and energy counter of the configured AIN
+func (a *AHAPowerMeter) GetDeviceInfo() (*AHADeviceInfo, error) {
+	body, err := a.command("getdeviceinfos", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var dev struct {
+		Name    string `xml:"name"`
+		Present int    `xml:"present"`
+		Switch  struct {
+			State string `xml:"state"`
+		} `xml:"switch"`
+		PowerMeter *struct {
+			Voltage string `xml:"voltage"`
+			Power   string `xml:"power"`
+			Energy  string `xml:"energy"`
+		} `xml:"powermeter"`
+	}
+	if err := xml.Unmarshal(body, &dev); err != nil {
+		return nil, fmt.Errorf("failed to parse device info: %w", err)
+	}
+	if dev.PowerMeter == nil {
+		return nil, fmt.Errorf("device %s has no power meter", a.AIN)
+	}
+
+	info := &AHADeviceInfo{
+		Name:     dev.Name,
+		Present:  dev.Present == 1,
+		SwitchOn: dev.Switch.State == "1",
+	}
+	// powermeter values: power in mW, voltage in mV, energy in Wh
+	if info.PowerMW, err = parseAHAValue(dev.PowerMeter.Power); err != nil {
+		return nil, fmt.Errorf("invalid power value: %w", err)
+	}
+	if info.VoltageMV, err = parseAHAValue(dev.PowerMeter.Voltage); err != nil {
+		return nil, fmt.Errorf("invalid voltage value: %w", err)
+	}
+	if info.EnergyWh, err = parseAHAValue(dev.PowerMeter.Energy); err != nil {
+		return nil, fmt.Errorf("invalid energy value: %w", err)
+	}
+	if !info.Present {
+		return info, fmt.Errorf("device %s is not connected", a.AIN)
+	}
+
+	return info, nil
+}
+
+// GetDeviceStats returns the voltage, power and energy history kept by the FritzBox
+func (a *AHAPowerMeter) GetDeviceStats() (*AHADeviceStats, error) {
+	body, err := a.command("getbasicdevicestats", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	type statsXML struct {
+		Count    int    `xml:"count,attr"`
+		Grid     int    `xml:"grid,attr"`
+		DataTime int64  `xml:"datatime,attr"`
+		Values   string `xml:",chardata"`
+	}
+	var raw struct {
+		Voltage []statsXML `xml:"voltage>stats"`
+		Power   []statsXML `xml:"power>stats"`
+		Energy  []statsXML `xml:"energy>stats"`
+	}
+	if err := xml.Unmarshal(body, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse device stats: %w", err)
+	}
+
+	convert := func(in []statsXML, factor float64) []AHAStatsSeries {
+		var out []AHAStatsSeries
+		for _, s := range in {
+			series := AHAStatsSeries{Grid: time.Duration(s.Grid) * time.Second}
+			if s.DataTime > 0 {
+				series.DataTime = time.Unix(s.DataTime, 0)
+			}
+			for _, field := range strings.Split(s.Values, ",") {
+				v, err := strconv.ParseFloat(strings.TrimSpace(field), 64)
+				if err != nil {
+					continue // "-" marks a missing value
+				}
+				series.Values = append(series.Values, v*factor)
+			}
+			out = append(out, series)
+		}
+		return out
+	}
+
+	return &AHADeviceStats{
+		VoltageMV: convert(raw.Voltage, 1),
+		PowerMW:   convert(raw.Power, 10), // power stats are in 0.01 W
+		EnergyWh:  convert(raw.Energy, 1),
+	}, nil
+}
+
+// command executes an AHA switchcmd for the configured AIN, logging in on demand.
+// A 403 response means the SID expired, so we log in again once and retry.
+func (a *AHAPowerMeter) command(cmd string, params url.Values) ([]byte, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if a.sid == "" {
+			if err := a.login(); err != nil {
+				return nil, err
+			}
+		}
+
+		q := url.Values{}
+		for k, v := range params {
+			q[k] = v
+		}
+		q.Set("switchcmd", cmd)
+		q.Set("ain", a.AIN)
+		q.Set("sid", a.sid)
+
+		resp, err := a.Client.Get(a.BaseURL + "/webservices/homeautoswitch.lua?" + q.Encode())
+		if err != nil {
+			return nil, fmt.Errorf("AHA request %s failed: %w", cmd, err)
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read AHA response: %w", err)
+		}
+
+		switch resp.StatusCode {
+		case http.StatusOK:
+			return body, nil
+		case http.StatusForbidden:
+			a.sid = "" // Session expired, log in again
+			continue
+		default:
+			return nil, fmt.Errorf("AHA request %s failed: %s", cmd, resp.Status)
+		}
+	}
+
+	return nil, fmt.Errorf("AHA request %s failed: session rejected after login", cmd)
+}
+
+// login performs the login_sid.lua challenge-response and stores the SID
+func (a *AHAPowerMeter) login() error {
+	info, err := a.fetchSessionInfo(nil)
+	if err != nil {
+		return err
+	}
+	if info.SID != invalidSID {
+		a.sid = info.SID
+		return nil
+	}
+	if info.BlockTime > 0 {
+		return fmt.Errorf("FritzBox login blocked for %ds", info.BlockTime)
+	}
+
+	response, err := solveChallenge(info.Challenge, a.Password)
+	if err != nil {
+		return err
+	}
+
+	form := url.Values{}
+	form.Set("username", a.Username)
+	form.Set("response", response)
+	info, err = a.fetchSessionInfo(form)
+	if err != nil {
+		return err
+	}
+	if info.SID == invalidSID {
+		return fmt.Errorf("FritzBox login failed for user %q", a.Username)
+	}
+
+	a.sid = info.SID
+	return nil
+}
+
+type sessionInfo struct {
+	SID       string `xml:"SID"`
+	Challenge string `xml:"Challenge"`
+	BlockTime int    `xml:"BlockTime"`
+}
+
+// fetchSessionInfo fetches login_sid.lua, posting the login form if given
+func (a *AHAPowerMeter) fetchSessionInfo(form url.Values) (*sessionInfo, error) {
+	loginURL := a.BaseURL + "/login_sid.lua?version=2"
+
+	var resp *http.Response
+	var err error
+	if form == nil {
+		resp, err = a.Client.Get(loginURL)
+	} else {
+		resp, err = a.Client.PostForm(loginURL, form)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("FritzBox login request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("FritzBox login request failed: %s", resp.Status)
+	}
+
+	var info sessionInfo
+	if err := xml.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to parse session info: %w", err)
+	}
+	return &info, nil
+}
+
+// solveChallenge computes the login response for a PBKDF2 ("2$...") or legacy MD5 challenge
+func solveChallenge(challenge, password string) (string, error) {
+	if !strings.HasPrefix(challenge, "2$") {
+		// Legacy: MD5 over UTF-16LE encoded "<challenge>-<password>"
+		var buf []byte
+		for _, c := range utf16.Encode([]rune(challenge + "-" + password)) {
+			buf = append(buf, byte(c), byte(c>>8))
+		}
+		sum := md5.Sum(buf)
+		return challenge + "-" + hex.EncodeToString(sum[:]), nil
+	}
+
+	// PBKDF2: 2$<iter1>$<salt1>$<iter2>$<salt2>
+	parts := strings.Split(challenge, "$")
+	if len(parts) != 5 {
+		return "", fmt.Errorf("malformed PBKDF2 challenge %q", challenge)
+	}
+	iter1, err1 := strconv.Atoi(parts[1])
+	salt1, err2 := hex.DecodeString(parts[2])
+	iter2, err3 := strconv.Atoi(parts[3])
+	salt2, err4 := hex.DecodeString(parts[4])
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return "", fmt.Errorf("malformed PBKDF2 challenge %q", challenge)
+	}
+
+	hash1, err := pbkdf2.Key(sha256.New, password, salt1, iter1, sha256.Size)
+	if err != nil {
+		return "", err
+	}
+	hash2, err := pbkdf2.Key(sha256.New, string(hash1), salt2, iter2, sha256.Size)
+	if err != nil {
+		return "", err
+	}
+	return parts[4] + "$" + hex.EncodeToString(hash2), nil
+}
+
+// parseAHAValue parses a numeric AHA field; empty values (meter not ready) read as 0
+func parseAHAValue(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
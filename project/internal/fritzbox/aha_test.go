@@ -0,0 +1,290 @@
+package fritzbox
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Challenges and responses from AVM's login_sid.lua documentation
+const (
+	pbkdf2Challenge = "2$10000$5A1711$2000$5A1722"
+	pbkdf2Password  = "1example!"
+	pbkdf2Response  = "5A1722$1798a1672bca7c6463d6b245f82b53703b0f50813401b03e4045a5861e689adb"
+
+	md5Challenge = "1234567z"
+	md5Password  = "äbc"
+	md5Response  = "1234567z-9e224a41eeefa284df7bb0f26c2913e2"
+)
+
+const testAIN = "11630 0123456"
+
+// fakeFritzBox emulates login_sid.lua and webservices/homeautoswitch.lua
+type fakeFritzBox struct {
+	challenge string
+	response  string // Login response expected for challenge
+	device    string // getdeviceinfos body
+
+	mu       sync.Mutex
+	logins   int
+	sessions map[string]bool
+	reject   bool // Answer every AHA request with 403
+	switchOn bool
+}
+
+func newFakeFritzBox(t *testing.T, challenge, response string) (*fakeFritzBox, *httptest.Server) {
+	fb := &fakeFritzBox{
+		challenge: challenge,
+		response:  response,
+		device:    ahaDevice("1", "1850", "230123", "4242"),
+		sessions:  make(map[string]bool),
+	}
+	srv := httptest.NewServer(fb)
+	t.Cleanup(srv.Close)
+	return fb, srv
+}
+
+func ahaDevice(present, power, voltage, energy string) string {
+	return fmt.Sprintf(`<device identifier="%s" id="16" functionbitmask="35712" fwversion="04.16" manufacturer="AVM" productname="FRITZ!DECT 200">`+
+		`<present>%s</present><txbusy>0</txbusy><name>Lab Plug</name>`+
+		`<switch><state>1</state><mode>manuell</mode><lock>0</lock><devicelock>0</devicelock></switch>`+
+		`<simpleonoff><state>1</state></simpleonoff>`+
+		`<powermeter><voltage>%s</voltage><power>%s</power><energy>%s</energy></powermeter>`+
+		`<temperature><celsius>225</celsius><offset>0</offset></temperature></device>`,
+		testAIN, present, voltage, power, energy)
+}
+
+// expire invalidates all sessions, as the FritzBox does after 20 minutes of inactivity
+func (fb *fakeFritzBox) expire() {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	fb.sessions = make(map[string]bool)
+}
+
+func (fb *fakeFritzBox) setDevice(device string) {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	fb.device = device
+}
+
+func (fb *fakeFritzBox) loginCount() int {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+	return fb.logins
+}
+
+func (fb *fakeFritzBox) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/login_sid.lua":
+		sid := invalidSID
+		if r.Method == http.MethodPost && r.FormValue("username") == "admin" && r.FormValue("response") == fb.response {
+			fb.logins++
+			sid = fmt.Sprintf("%016x", fb.logins)
+			fb.sessions[sid] = true
+		}
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="utf-8"?><SessionInfo><SID>%s</SID><Challenge>%s</Challenge><BlockTime>0</BlockTime><Rights></Rights></SessionInfo>`,
+			sid, fb.challenge)
+	case "/webservices/homeautoswitch.lua":
+		q := r.URL.Query()
+		if fb.reject || !fb.sessions[q.Get("sid")] {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		if q.Get("ain") != testAIN {
+			http.Error(w, "unknown AIN", http.StatusBadRequest)
+			return
+		}
+		switch q.Get("switchcmd") {
+		case "getdeviceinfos":
+			fmt.Fprint(w, fb.device)
+		case "getbasicdevicestats":
+			fmt.Fprint(w, `<devicestats>`+
+				`<voltage><stats count="3" grid="10" datatime="1700000000">230123,-,229876</stats></voltage>`+
+				`<power><stats count="3" grid="10">185,190,-</stats></power>`+
+				`<energy><stats count="2" grid="3600">12,13</stats><stats count="1" grid="86400">300</stats></energy>`+
+				`</devicestats>`)
+		case "setswitchon", "setswitchoff":
+			fb.switchOn = q.Get("switchcmd") == "setswitchon"
+			fmt.Fprint(w, "1\n")
+		default:
+			http.Error(w, "unknown command", http.StatusBadRequest)
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestAHALoginPBKDF2(t *testing.T) {
+	fb, srv := newFakeFritzBox(t, pbkdf2Challenge, pbkdf2Response)
+	m := NewAHAPowerMeter(srv.URL, "admin", pbkdf2Password, testAIN)
+
+	sample, err := m.GetSample()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sample.PowerMW != 1850 {
+		t.Errorf("PowerMW = %v, want 1850", sample.PowerMW)
+	}
+	// Voltage is reported in mV
+	if sample.VoltageV == nil || math.Abs(*sample.VoltageV-230.123) > 1e-9 {
+		t.Errorf("VoltageV = %v, want 230.123", sample.VoltageV)
+	}
+	if sample.EnergyWh == nil || *sample.EnergyWh != 4242 {
+		t.Errorf("EnergyWh = %v, want 4242", sample.EnergyWh)
+	}
+
+	// The session is reused
+	if _, err := m.GetCurrentPower(); err != nil {
+		t.Fatal(err)
+	}
+	if n := fb.loginCount(); n != 1 {
+		t.Errorf("logins = %d, want 1", n)
+	}
+}
+
+func TestAHALoginMD5(t *testing.T) {
+	_, srv := newFakeFritzBox(t, md5Challenge, md5Response)
+	m := NewAHAPowerMeter(srv.URL, "admin", md5Password, testAIN)
+
+	if err := m.TestConnection(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAHALoginWrongPassword(t *testing.T) {
+	_, srv := newFakeFritzBox(t, pbkdf2Challenge, pbkdf2Response)
+	m := NewAHAPowerMeter(srv.URL, "admin", "wrong", testAIN)
+
+	if _, err := m.GetCurrentPower(); err == nil || !strings.Contains(err.Error(), "login failed") {
+		t.Fatalf("err = %v, want login failure", err)
+	}
+}
+
+func TestAHAReloginAfterExpiredSession(t *testing.T) {
+	fb, srv := newFakeFritzBox(t, md5Challenge, md5Response)
+	m := NewAHAPowerMeter(srv.URL, "admin", md5Password, testAIN)
+
+	if _, err := m.GetCurrentPower(); err != nil {
+		t.Fatal(err)
+	}
+	fb.expire()
+	if _, err := m.GetCurrentPower(); err != nil {
+		t.Fatalf("no re-login after expired session: %v", err)
+	}
+	if n := fb.loginCount(); n != 2 {
+		t.Errorf("logins = %d, want 2", n)
+	}
+
+	// A SID that is not accepted right after logging in is an error, not a loop
+	fb.mu.Lock()
+	fb.reject = true
+	fb.mu.Unlock()
+	if _, err := m.GetCurrentPower(); err == nil || !strings.Contains(err.Error(), "session rejected") {
+		t.Fatalf("err = %v, want session rejected", err)
+	}
+}
+
+func TestAHADeviceInfo(t *testing.T) {
+	fb, srv := newFakeFritzBox(t, md5Challenge, md5Response)
+	m := NewAHAPowerMeter(srv.URL, "admin", md5Password, testAIN)
+
+	info, err := m.GetDeviceInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := AHADeviceInfo{Name: "Lab Plug", Present: true, SwitchOn: true, PowerMW: 1850, VoltageMV: 230123, EnergyWh: 4242}
+	if *info != want {
+		t.Errorf("got %+v, want %+v", *info, want)
+	}
+
+	// Empty values while the plug has not measured yet read as 0
+	fb.setDevice(ahaDevice("1", "", "", ""))
+	if info, err = m.GetDeviceInfo(); err != nil {
+		t.Fatal(err)
+	}
+	if info.PowerMW != 0 || info.VoltageMV != 0 || info.EnergyWh != 0 {
+		t.Errorf("empty values: got %+v", *info)
+	}
+
+	fb.setDevice(ahaDevice("0", "0", "0", "4242"))
+	if _, err := m.GetDeviceInfo(); err == nil || !strings.Contains(err.Error(), "not connected") {
+		t.Errorf("err = %v, want device not connected", err)
+	}
+
+	fb.setDevice(ahaDevice("1", "12.5.3", "230000", "1"))
+	if _, err := m.GetDeviceInfo(); err == nil {
+		t.Error("invalid power value accepted")
+	}
+
+	fb.setDevice(`<device identifier="` + testAIN + `"><present>1</present><name>Thermostat</name></device>`)
+	if _, err := m.GetDeviceInfo(); err == nil || !strings.Contains(err.Error(), "no power meter") {
+		t.Errorf("err = %v, want no power meter", err)
+	}
+}
+
+func TestAHADeviceStats(t *testing.T) {
+	_, srv := newFakeFritzBox(t, md5Challenge, md5Response)
+	m := NewAHAPowerMeter(srv.URL, "admin", md5Password, testAIN)
+
+	stats, err := m.GetDeviceStats()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stats.VoltageMV) != 1 {
+		t.Fatalf("voltage series = %d, want 1", len(stats.VoltageMV))
+	}
+	voltage := stats.VoltageMV[0]
+	if voltage.Grid != 10*time.Second || !voltage.DataTime.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("voltage grid %s at %s", voltage.Grid, voltage.DataTime)
+	}
+	// Missing values ("-") are skipped
+	if fmt.Sprint(voltage.Values) != "[230123 229876]" {
+		t.Errorf("voltage values = %v", voltage.Values)
+	}
+
+	// Power statistics are in 0.01 W
+	if len(stats.PowerMW) != 1 || fmt.Sprint(stats.PowerMW[0].Values) != "[1850 1900]" {
+		t.Errorf("power = %+v, want [1850 1900] mW", stats.PowerMW)
+	}
+	if !stats.PowerMW[0].DataTime.IsZero() {
+		t.Errorf("power DataTime = %s, want zero", stats.PowerMW[0].DataTime)
+	}
+
+	if len(stats.EnergyWh) != 2 || stats.EnergyWh[1].Grid != 24*time.Hour || fmt.Sprint(stats.EnergyWh[0].Values) != "[12 13]" {
+		t.Errorf("energy = %+v", stats.EnergyWh)
+	}
+}
+
+func TestAHASetSwitch(t *testing.T) {
+	fb, srv := newFakeFritzBox(t, md5Challenge, md5Response)
+	m := NewAHAPowerMeter(srv.URL, "admin", md5Password, testAIN)
+
+	for _, on := range []bool{true, false} {
+		if err := m.SetSwitch(on); err != nil {
+			t.Fatal(err)
+		}
+		fb.mu.Lock()
+		got := fb.switchOn
+		fb.mu.Unlock()
+		if got != on {
+			t.Errorf("SetSwitch(%v): outlet on = %v", on, got)
+		}
+	}
+}
+
+func TestSolveChallengeMalformed(t *testing.T) {
+	for _, challenge := range []string{"2$10000$5A1711$2000", "2$x$5A1711$2000$5A1722", "2$10000$zz$2000$5A1722"} {
+		if _, err := solveChallenge(challenge, "secret"); err == nil {
+			t.Errorf("%q: expected error", challenge)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"math/rand"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/nitram509/gofritz/pkg/soap"
 	"github.com/nitram509/gofritz/pkg/tr064/gateway"
@@ -18,6 +19,54 @@ type PowerMeter interface {
 	TestConnection() error
 }
 
+// Sample is a single reading of all quantities a meter can report.
+// Optional quantities are nil when the meter does not provide them.
+type Sample struct {
+	PowerMW        float64    // Active power in milliwatts (mW)
+	VoltageV       *float64   // RMS voltage in volts
+	CurrentA       *float64   // RMS current in amperes
+	PowerFactor    *float64   // Power factor (0..1)
+	EnergyWh       *float64   // Cumulative energy counter in Wh
+	MeterTimestamp *time.Time // Measurement time reported by the meter itself
+}
+
+// SampleReader is an optional capability for meters that report more than power
+type SampleReader interface {
+	// GetSample returns the current reading including all supported quantities
+	GetSample() (Sample, error)
+}
+
+// Switcher is an optional capability for meters that can switch the DUT's outlet
+type Switcher interface {
+	// SetSwitch turns the outlet on or off
+	SetSwitch(on bool) error
+}
+
+// Resetter is an optional capability for meters that play back a recording and
+// restart it at the beginning of each test
+type Resetter interface {
+	// Reset restarts playback from the beginning
+	Reset()
+}
+
+// ReadSample reads a full sample if the meter implements SampleReader,
+// otherwise it falls back to GetCurrentPower.
+func ReadSample(m PowerMeter) (Sample, error) {
+	if sr, ok := m.(SampleReader); ok {
+		return sr.GetSample()
+	}
+	power, err := m.GetCurrentPower()
+	if err != nil {
+		return Sample{}, err
+	}
+	return Sample{PowerMW: power}, nil
+}
+
+// floatPtr returns a pointer to v (for optional Sample fields)
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
 // MockPowerMeter generates random power consumption data for testing
 type MockPowerMeter struct {
 	basePower float64
@@ -71,10 +120,19 @@ func NewRealPowerMeter(urlStr, username, password, ain string) *RealPowerMeter {
 }
 
 func (r *RealPowerMeter) GetCurrentPower() (float64, error) {
-	resp, err := gateway.GetSpecificDeviceInfos(r.Session, r.AIN)
+	sample, err := r.GetSample()
 	if err != nil {
 		return 0, err
 	}
+	return sample.PowerMW, nil
+}
+
+// GetSample returns power and the energy counter from GetSpecificDeviceInfos
+func (r *RealPowerMeter) GetSample() (Sample, error) {
+	resp, err := gateway.GetSpecificDeviceInfos(r.Session, r.AIN)
+	if err != nil {
+		return Sample{}, err
+	}
 	
 	// Debug log to see what we get
 	fmt.Printf("Device Info: Power=%d, Energy=%d, Present=%s, Switch=%s\n", 
@@ -82,7 +140,23 @@ func (r *RealPowerMeter) GetCurrentPower() (float64, error) {
 
 	// MultimeterPower is in 0.01 W (centiwatt)
 	// We want mW. 1 cW = 10 mW.
-	return float64(resp.MultimeterPower) * 10.0, nil
+	// MultimeterEnergy is in Wh.
+	return Sample{
+		PowerMW:  float64(resp.MultimeterPower) * 10.0,
+		EnergyWh: floatPtr(float64(resp.MultimeterEnergy)),
+	}, nil
+}
+
+// SetSwitch switches the outlet via X_AVM-DE_Homeauto SetSwitch
+func (r *RealPowerMeter) SetSwitch(on bool) error {
+	state := "OFF"
+	if on {
+		state = "ON"
+	}
+	if _, err := gateway.SetSwitch(r.Session, r.AIN, state); err != nil {
+		return fmt.Errorf("failed to switch %s %s: %w", r.AIN, state, err)
+	}
+	return nil
 }
 
 func (r *RealPowerMeter) TestConnection() error {
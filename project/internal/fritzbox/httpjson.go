@@ -0,0 +1,300 @@
+package fritzbox
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTTPJSONPowerMeter polls any HTTP endpoint returning JSON and extracts the
+// quantities with simple JSONPath expressions (e.g. "$.meters[0].power").
+type HTTPJSONPowerMeter struct {
+	URL      string
+	Username string // Optional basic auth
+	Password string
+	Client   *http.Client
+
+	PowerPath  string  // Required
+	PowerScale float64 // Multiplier from the device unit to mW (e.g. 1000 for W)
+
+	// Optional paths, values are expected in V, A, Wh and as 0..1
+	VoltagePath     string
+	CurrentPath     string
+	EnergyPath      string
+	EnergyScale     float64 // Multiplier from the device unit to Wh (default 1)
+	PowerFactorPath string
+}
+
+func NewHTTPJSONPowerMeter(url, powerPath string, powerScale float64) *HTTPJSONPowerMeter {
+	if powerScale == 0 {
+		powerScale = 1000 // Most devices report W
+	}
+
+	fmt.Printf("Initializing HTTPJSONPowerMeter with URL: %s, Path: %s\n", url, powerPath)
+
+	return &HTTPJSONPowerMeter{
+		URL:         url,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		PowerPath:   powerPath,
+		PowerScale:  powerScale,
+		EnergyScale: 1,
+	}
+}
+
+func (h *HTTPJSONPowerMeter) GetCurrentPower() (float64, error) {
+	sample, err := h.GetSample()
+	if err != nil {
+		return 0, err
+	}
+	return sample.PowerMW, nil
+}
+
+// GetSample fetches the URL once and extracts all configured paths
+func (h *HTTPJSONPowerMeter) GetSample() (Sample, error) {
+	var doc interface{}
+	if err := getJSON(h.Client, h.URL, h.Username, h.Password, &doc); err != nil {
+		return Sample{}, err
+	}
+
+	power, err := jsonPathFloat(doc, h.PowerPath)
+	if err != nil {
+		return Sample{}, err
+	}
+	sample := Sample{PowerMW: power * h.PowerScale}
+
+	optional := []struct {
+		path  string
+		scale float64
+		dst   **float64
+	}{
+		{h.VoltagePath, 1, &sample.VoltageV},
+		{h.CurrentPath, 1, &sample.CurrentA},
+		{h.EnergyPath, h.EnergyScale, &sample.EnergyWh},
+		{h.PowerFactorPath, 1, &sample.PowerFactor},
+	}
+	for _, o := range optional {
+		if o.path == "" {
+			continue
+		}
+		v, err := jsonPathFloat(doc, o.path)
+		if err != nil {
+			return Sample{}, err
+		}
+		*o.dst = floatPtr(v * o.scale)
+	}
+
+	return sample, nil
+}
+
+func (h *HTTPJSONPowerMeter) TestConnection() error {
+	_, err := h.GetSample()
+	return err
+}
+
+// getJSON performs a GET request and decodes the JSON response into v.
+// Credentials are sent with basic auth, a digest challenge is answered once.
+func getJSON(client *http.Client, url, username, password string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", url, err)
+	}
+
+	challenge := resp.Header.Get("WWW-Authenticate")
+	if resp.StatusCode == http.StatusUnauthorized && username != "" && strings.HasPrefix(challenge, "Digest ") {
+		resp.Body.Close()
+		auth, err := digestAuthorization(challenge, req.Method, req.URL.RequestURI(), username, password)
+		if err != nil {
+			return fmt.Errorf("request to %s failed: %w", url, err)
+		}
+		req.Header.Set("Authorization", auth)
+		if resp, err = client.Do(req); err != nil {
+			return fmt.Errorf("request to %s failed: %w", url, err)
+		}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s failed: %s", url, resp.Status)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode response from %s: %w", url, err)
+	}
+	return nil
+}
+
+// digestAuthorization answers an HTTP digest challenge (RFC 7616) with
+// qop "auth" or none, using MD5 or SHA-256 as Shelly Gen2+ devices require
+func digestAuthorization(challenge, method, uri, username, password string) (string, error) {
+	params := parseAuthParams(strings.TrimPrefix(challenge, "Digest "))
+
+	var hash func(string) string
+	switch algorithm := params["algorithm"]; strings.ToUpper(algorithm) {
+	case "", "MD5":
+		hash = func(s string) string {
+			sum := md5.Sum([]byte(s))
+			return hex.EncodeToString(sum[:])
+		}
+	case "SHA-256":
+		hash = func(s string) string {
+			sum := sha256.Sum256([]byte(s))
+			return hex.EncodeToString(sum[:])
+		}
+	default:
+		return "", fmt.Errorf("unsupported digest algorithm %q", algorithm)
+	}
+
+	realm, nonce := params["realm"], params["nonce"]
+	ha1 := hash(username + ":" + realm + ":" + password)
+	ha2 := hash(method + ":" + uri)
+
+	auth := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s"`, username, realm, nonce, uri)
+	if qops := params["qop"]; qops != "" {
+		if !slices.Contains(strings.Split(strings.ReplaceAll(qops, " ", ""), ","), "auth") {
+			return "", fmt.Errorf("unsupported digest qop %q", qops)
+		}
+		cnonceBytes := make([]byte, 8)
+		if _, err := rand.Read(cnonceBytes); err != nil {
+			return "", err
+		}
+		cnonce, nc := hex.EncodeToString(cnonceBytes), "00000001"
+		response := hash(ha1 + ":" + nonce + ":" + nc + ":" + cnonce + ":auth:" + ha2)
+		auth += fmt.Sprintf(`, qop=auth, nc=%s, cnonce="%s", response="%s"`, nc, cnonce, response)
+	} else {
+		auth += fmt.Sprintf(`, response="%s"`, hash(ha1+":"+nonce+":"+ha2))
+	}
+	if algorithm := params["algorithm"]; algorithm != "" {
+		auth += ", algorithm=" + algorithm
+	}
+	if opaque := params["opaque"]; opaque != "" {
+		auth += fmt.Sprintf(`, opaque="%s"`, opaque)
+	}
+	return auth, nil
+}
+
+// parseAuthParams splits a comma-separated list of key=value pairs with optionally quoted values
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for s != "" {
+		s = strings.TrimLeft(s, " ,")
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.IndexByte(s[1:], '"')
+			if end < 0 {
+				end = len(s) - 1
+			}
+			value, s = s[1:end+1], s[min(end+2, len(s)):]
+		} else {
+			end := strings.IndexByte(s, ',')
+			if end < 0 {
+				end = len(s)
+			}
+			value, s = strings.TrimSpace(s[:end]), s[end:]
+		}
+		params[key] = value
+	}
+	return params
+}
+
+// jsonPathFloat evaluates a simple JSONPath ("$.a.b[0].c") and returns the value as float64.
+// Only child access by name and array indices are supported.
+func jsonPathFloat(doc interface{}, path string) (float64, error) {
+	v, err := jsonPath(doc, path)
+	if err != nil {
+		return 0, err
+	}
+
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		if err != nil {
+			return 0, fmt.Errorf("value at %s is not numeric: %q", path, n)
+		}
+		return f, nil
+	case bool:
+		if n {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("value at %s is not numeric", path)
+	}
+}
+
+// jsonPath walks a decoded JSON document along path
+func jsonPath(doc interface{}, path string) (interface{}, error) {
+	p := strings.TrimPrefix(strings.TrimSpace(path), "$")
+	cur := doc
+
+	for p != "" {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			key := p[:end]
+			p = p[end:]
+
+			obj, ok := cur.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("path %s: %q is not an object key", path, key)
+			}
+			if cur, ok = obj[key]; !ok {
+				return nil, fmt.Errorf("path %s: key %q not found", path, key)
+			}
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("path %s: missing ]", path)
+			}
+			token := strings.Trim(p[1:end], `'"`)
+			p = p[end+1:]
+
+			if arr, ok := cur.([]interface{}); ok {
+				idx, err := strconv.Atoi(token)
+				if err != nil || idx < 0 || idx >= len(arr) {
+					return nil, fmt.Errorf("path %s: invalid index %q", path, token)
+				}
+				cur = arr[idx]
+			} else if obj, ok := cur.(map[string]interface{}); ok {
+				// Bracket notation for keys with spaces or dots
+				if cur, ok = obj[token]; !ok {
+					return nil, fmt.Errorf("path %s: key %q not found", path, token)
+				}
+			} else {
+				return nil, fmt.Errorf("path %s: cannot index %q", path, token)
+			}
+		default:
+			return nil, fmt.Errorf("path %s: unexpected %q", path, p[0])
+		}
+	}
+
+	return cur, nil
+}
@@ -0,0 +1,118 @@
+package fritzbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testJSONDoc = `{
+	"meters": [{"power": 12.5, "total": "3.5"}, {"power": 7}],
+	"emeter": {"voltage": 231.4, "current": 0.2, "pf": 0.9, "on": true, "state": "ok", "name with.dots": 42, "none": null}
+}`
+
+func TestJSONPathFloat(t *testing.T) {
+	var doc interface{}
+	if err := json.Unmarshal([]byte(testJSONDoc), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want float64
+	}{
+		{"$.meters[0].power", 12.5},
+		{"$.meters[1].power", 7},
+		{"$.meters[0].total", 3.5}, // Numeric string
+		{"$.emeter.voltage", 231.4},
+		{"$.emeter.on", 1},
+		{`$.emeter["name with.dots"]`, 42},
+		{"$['emeter']['pf']", 0.9},
+	}
+	for _, tt := range tests {
+		got, err := jsonPathFloat(doc, tt.path)
+		if err != nil {
+			t.Errorf("%s: %v", tt.path, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	failures := []struct {
+		path string
+		want string
+	}{
+		{"$.meters[0].energy", "not found"},
+		{"$.missing.power", "not found"},
+		{"$.meters[2].power", "invalid index"},
+		{"$.meters[-1].power", "invalid index"},
+		{"$.meters[x].power", "invalid index"},
+		{"$.meters[0", "missing ]"},
+		{"$.emeter.voltage.value", "not an object key"},
+		{"$.emeter.voltage[0]", "cannot index"},
+		{"$.emeter.state", "not numeric"},
+		{"$.emeter.none", "not numeric"},
+		{"$.meters", "not numeric"},
+		{"$meters", "unexpected"},
+	}
+	for _, tt := range failures {
+		if _, err := jsonPathFloat(doc, tt.path); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.path, err, tt.want)
+		}
+	}
+}
+
+func TestHTTPJSONPowerMeter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/status":
+			fmt.Fprint(w, testJSONDoc)
+		case "/broken":
+			fmt.Fprint(w, `{"meters": [`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	m := NewHTTPJSONPowerMeter(srv.URL+"/status", "$.meters[0].power", 0)
+	m.VoltagePath = "$.emeter.voltage"
+	m.EnergyPath = "$.meters[0].total"
+	m.EnergyScale = 1000 // kWh
+	m.PowerFactorPath = "$.emeter.pf"
+
+	sample, err := m.GetSample()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Default scale: W to mW
+	if sample.PowerMW != 12500 {
+		t.Errorf("PowerMW = %v, want 12500", sample.PowerMW)
+	}
+	if sample.VoltageV == nil || *sample.VoltageV != 231.4 {
+		t.Errorf("VoltageV = %v, want 231.4", sample.VoltageV)
+	}
+	if sample.EnergyWh == nil || *sample.EnergyWh != 3500 {
+		t.Errorf("EnergyWh = %v, want 3500", sample.EnergyWh)
+	}
+	if sample.CurrentA != nil {
+		t.Errorf("CurrentA = %v, want nil without a path", *sample.CurrentA)
+	}
+
+	// A configured optional path that is missing fails the reading
+	m.CurrentPath = "$.emeter.amps"
+	if _, err := m.GetSample(); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("err = %v, want missing key", err)
+	}
+
+	for _, url := range []string{srv.URL + "/broken", srv.URL + "/missing"} {
+		if _, err := NewHTTPJSONPowerMeter(url, "$.meters[0].power", 1).GetCurrentPower(); err == nil {
+			t.Errorf("%s: expected error", url)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+package fritzbox
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ModbusRegister describes where and how a quantity is stored on a Modbus device
+type ModbusRegister struct {
+	Address   uint16
+	Input     bool    // Input register (function 04) instead of holding register (function 03)
+	Type      string  // "float32", "int32", "uint32", "int16" or "uint16"
+	Scale     float64 // Multiplier from the raw value to W, V, A or Wh (0 = 1)
+	WordOrder string  // "big" (high word first, default) or "little" (low word first)
+}
+
+// ModbusMapping maps quantities to registers. Power is required, all others are optional.
+type ModbusMapping struct {
+	Power       *ModbusRegister // W
+	Voltage     *ModbusRegister // V
+	Current     *ModbusRegister // A
+	PowerFactor *ModbusRegister
+	Energy      *ModbusRegister // Wh
+}
+
+// ModbusPresets contains register maps for common DIN-rail energy meters (phase 1)
+var ModbusPresets = map[string]ModbusMapping{
+	// Eastron SDM120/SDM230/SDM630: float32 input registers, energy in kWh
+	"sdm": {
+		Voltage:     &ModbusRegister{Address: 0x0000, Input: true, Type: "float32"},
+		Current:     &ModbusRegister{Address: 0x0006, Input: true, Type: "float32"},
+		Power:       &ModbusRegister{Address: 0x000C, Input: true, Type: "float32"},
+		PowerFactor: &ModbusRegister{Address: 0x001E, Input: true, Type: "float32"},
+		Energy:      &ModbusRegister{Address: 0x0048, Input: true, Type: "float32", Scale: 1000},
+	},
+	// Carlo Gavazzi EM24/EM340: int32 with low word first, fixed-point scaling
+	"carlo-gavazzi": {
+		Voltage:     &ModbusRegister{Address: 0x0000, Type: "int32", Scale: 0.1, WordOrder: "little"},
+		Current:     &ModbusRegister{Address: 0x000C, Type: "int32", Scale: 0.001, WordOrder: "little"},
+		Power:       &ModbusRegister{Address: 0x0012, Type: "int32", Scale: 0.1, WordOrder: "little"},
+		PowerFactor: &ModbusRegister{Address: 0x002E, Type: "int16", Scale: 0.001},
+		Energy:      &ModbusRegister{Address: 0x0034, Type: "int32", Scale: 100, WordOrder: "little"},
+	},
+}
+
+// ParseModbusRegister parses "<holding|input>:<address>:<type>[:<scale>[:<big|little>]]",
+// e.g. "input:0x000C:float32" or "holding:18:int32:0.1:little"
+func ParseModbusRegister(spec string) (*ModbusRegister, error) {
+	parts := strings.Split(spec, ":")
+	if len(parts) < 3 || len(parts) > 5 {
+		return nil, fmt.Errorf("invalid modbus register %q", spec)
+	}
+
+	reg := &ModbusRegister{Type: parts[2]}
+	switch parts[0] {
+	case "holding":
+	case "input":
+		reg.Input = true
+	default:
+		return nil, fmt.Errorf("invalid modbus register kind %q", parts[0])
+	}
+
+	addr, err := strconv.ParseUint(parts[1], 0, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modbus register address %q", parts[1])
+	}
+	reg.Address = uint16(addr)
+
+	if len(parts) > 3 {
+		if reg.Scale, err = strconv.ParseFloat(parts[3], 64); err != nil {
+			return nil, fmt.Errorf("invalid modbus scale %q", parts[3])
+		}
+	}
+	if len(parts) > 4 {
+		reg.WordOrder = parts[4]
+	}
+
+	if _, err := reg.words(); err != nil {
+		return nil, err
+	}
+	return reg, nil
+}
+
+// words returns the number of 16-bit registers occupied by the value
+func (r *ModbusRegister) words() (uint16, error) {
+	switch r.Type {
+	case "float32", "int32", "uint32":
+		return 2, nil
+	case "int16", "uint16":
+		return 1, nil
+	default:
+		return 0, fmt.Errorf("unsupported modbus data type %q", r.Type)
+	}
+}
+
+// decode converts raw register bytes (big-endian per register) to a scaled value
+func (r *ModbusRegister) decode(data []byte) (float64, error) {
+	if len(data) == 4 && r.WordOrder == "little" {
+		data = []byte{data[2], data[3], data[0], data[1]}
+	}
+
+	var v float64
+	switch r.Type {
+	case "float32":
+		v = float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+	case "int32":
+		v = float64(int32(binary.BigEndian.Uint32(data)))
+	case "uint32":
+		v = float64(binary.BigEndian.Uint32(data))
+	case "int16":
+		v = float64(int16(binary.BigEndian.Uint16(data)))
+	case "uint16":
+		v = float64(binary.BigEndian.Uint16(data))
+	default:
+		return 0, fmt.Errorf("unsupported modbus data type %q", r.Type)
+	}
+
+	if r.Scale != 0 {
+		v *= r.Scale
+	}
+	return v, nil
+}
+
+// ModbusPowerMeter reads an energy meter through a Modbus TCP gateway
+type ModbusPowerMeter struct {
+	Address string // host:port
+	UnitID  byte
+	Mapping ModbusMapping
+	Timeout time.Duration
+
+	mu            sync.Mutex
+	conn          net.Conn
+	transactionID uint16
+}
+
+func NewModbusPowerMeter(address string, unitID byte, mapping ModbusMapping) *ModbusPowerMeter {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "502")
+	}
+
+	fmt.Printf("Initializing ModbusPowerMeter with Address: %s, Unit: %d\n", address, unitID)
+
+	return &ModbusPowerMeter{
+		Address: address,
+		UnitID:  unitID,
+		Mapping: mapping,
+		Timeout: 5 * time.Second,
+	}
+}
+
+func (m *ModbusPowerMeter) GetCurrentPower() (float64, error) {
+	sample, err := m.GetSample()
+	if err != nil {
+		return 0, err
+	}
+	return sample.PowerMW, nil
+}
+
+// GetSample reads all mapped registers
+func (m *ModbusPowerMeter) GetSample() (Sample, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.Mapping.Power == nil {
+		return Sample{}, fmt.Errorf("no modbus power register configured")
+	}
+	watts, err := m.readRegister(m.Mapping.Power)
+	if err != nil {
+		return Sample{}, err
+	}
+	sample := Sample{PowerMW: watts * 1000}
+
+	optional := []struct {
+		reg *ModbusRegister
+		dst **float64
+	}{
+		{m.Mapping.Voltage, &sample.VoltageV},
+		{m.Mapping.Current, &sample.CurrentA},
+		{m.Mapping.PowerFactor, &sample.PowerFactor},
+		{m.Mapping.Energy, &sample.EnergyWh},
+	}
+	for _, o := range optional {
+		if o.reg == nil {
+			continue
+		}
+		v, err := m.readRegister(o.reg)
+		if err != nil {
+			return Sample{}, err
+		}
+		*o.dst = floatPtr(v)
+	}
+
+	return sample, nil
+}
+
+func (m *ModbusPowerMeter) TestConnection() error {
+	_, err := m.GetSample()
+	return err
+}
+
+// Close closes the gateway connection
+func (m *ModbusPowerMeter) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.disconnect()
+}
+
+func (m *ModbusPowerMeter) disconnect() error {
+	if m.conn == nil {
+		return nil
+	}
+	err := m.conn.Close()
+	m.conn = nil
+	return err
+}
+
+func (m *ModbusPowerMeter) readRegister(reg *ModbusRegister) (float64, error) {
+	count, err := reg.words()
+	if err != nil {
+		return 0, err
+	}
+
+	functionCode := byte(0x03)
+	if reg.Input {
+		functionCode = 0x04
+	}
+
+	data, err := m.readRegisters(functionCode, reg.Address, count)
+	if err != nil {
+		return 0, err
+	}
+	return reg.decode(data)
+}
+
+// readRegisters performs a read holding/input registers request (caller holds mu).
+// On I/O errors the connection is dropped so the next call reconnects.
+func (m *ModbusPowerMeter) readRegisters(functionCode byte, address, count uint16) ([]byte, error) {
+	if m.conn == nil {
+		conn, err := net.DialTimeout("tcp", m.Address, m.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to modbus gateway %s: %w", m.Address, err)
+		}
+		m.conn = conn
+	}
+
+	m.transactionID++
+
+	// MBAP header (transaction, protocol 0, length, unit) + PDU (function, address, count)
+	req := make([]byte, 12)
+	binary.BigEndian.PutUint16(req[0:], m.transactionID)
+	binary.BigEndian.PutUint16(req[2:], 0)
+	binary.BigEndian.PutUint16(req[4:], 6)
+	req[6] = m.UnitID
+	req[7] = functionCode
+	binary.BigEndian.PutUint16(req[8:], address)
+	binary.BigEndian.PutUint16(req[10:], count)
+
+	m.conn.SetDeadline(time.Now().Add(m.Timeout))
+	if _, err := m.conn.Write(req); err != nil {
+		m.disconnect()
+		return nil, fmt.Errorf("modbus write failed: %w", err)
+	}
+
+	header := make([]byte, 7)
+	if _, err := io.ReadFull(m.conn, header); err != nil {
+		m.disconnect()
+		return nil, fmt.Errorf("modbus read failed: %w", err)
+	}
+	length := binary.BigEndian.Uint16(header[4:])
+	if length < 2 || length > 254 {
+		m.disconnect()
+		return nil, fmt.Errorf("modbus response has invalid length %d", length)
+	}
+	pdu := make([]byte, length-1)
+	if _, err := io.ReadFull(m.conn, pdu); err != nil {
+		m.disconnect()
+		return nil, fmt.Errorf("modbus read failed: %w", err)
+	}
+
+	if id := binary.BigEndian.Uint16(header[0:]); id != m.transactionID {
+		m.disconnect()
+		return nil, fmt.Errorf("modbus transaction mismatch: sent %d, got %d", m.transactionID, id)
+	}
+	if len(pdu) < 2 {
+		return nil, fmt.Errorf("modbus response for register %d is malformed", address)
+	}
+	if pdu[0] == functionCode|0x80 {
+		return nil, fmt.Errorf("modbus exception %d reading register %d", pdu[1], address)
+	}
+	if pdu[0] != functionCode || int(pdu[1]) != int(count)*2 || len(pdu) < 2+int(count)*2 {
+		return nil, fmt.Errorf("modbus response for register %d is malformed", address)
+	}
+
+	return pdu[2 : 2+int(count)*2], nil
+}
@@ -0,0 +1,194 @@
+package fritzbox
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"net"
+	"strings"
+	"testing"
+)
+
+// fakeModbusServer answers read requests over Modbus TCP with the PDU
+// returned by respond, until the test ends
+func fakeModbusServer(t *testing.T, respond func(function byte, address, count uint16) []byte) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				for {
+					req := make([]byte, 12)
+					if _, err := io.ReadFull(conn, req); err != nil {
+						return
+					}
+					pdu := respond(req[7], binary.BigEndian.Uint16(req[8:]), binary.BigEndian.Uint16(req[10:]))
+					resp := make([]byte, 7, 7+len(pdu))
+					copy(resp, req[:4])
+					binary.BigEndian.PutUint16(resp[4:], uint16(len(pdu)+1))
+					resp[6] = req[6]
+					if _, err := conn.Write(append(resp, pdu...)); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return ln.Addr().String()
+}
+
+// registerResponse serves the registers of a register bank, missing
+// addresses get exception 2 (illegal data address)
+func registerResponse(holding, input map[uint16]uint16) func(byte, uint16, uint16) []byte {
+	return func(function byte, address, count uint16) []byte {
+		bank := holding
+		if function == 0x04 {
+			bank = input
+		}
+		pdu := []byte{function, byte(count * 2)}
+		for i := uint16(0); i < count; i++ {
+			v, ok := bank[address+i]
+			if !ok {
+				return []byte{function | 0x80, 2}
+			}
+			pdu = binary.BigEndian.AppendUint16(pdu, v)
+		}
+		return pdu
+	}
+}
+
+func float32Words(v float32) (uint16, uint16) {
+	bits := math.Float32bits(v)
+	return uint16(bits >> 16), uint16(bits)
+}
+
+func TestModbusSDMFloat32(t *testing.T) {
+	input := make(map[uint16]uint16)
+	for addr, v := range map[uint16]float32{0x0000: 230.5, 0x0006: 0.25, 0x000C: 42.5, 0x001E: 0.9, 0x0048: 12.345} {
+		input[addr], input[addr+1] = float32Words(v)
+	}
+	m := NewModbusPowerMeter(fakeModbusServer(t, registerResponse(nil, input)), 1, ModbusPresets["sdm"])
+	defer m.Close()
+
+	sample, err := m.GetSample()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sample.PowerMW != 42500 {
+		t.Errorf("PowerMW = %v, want 42500", sample.PowerMW)
+	}
+	if sample.VoltageV == nil || math.Abs(*sample.VoltageV-230.5) > 1e-3 {
+		t.Errorf("VoltageV = %v, want 230.5", sample.VoltageV)
+	}
+	// kWh register scaled to Wh
+	if sample.EnergyWh == nil || math.Abs(*sample.EnergyWh-12345) > 0.01 {
+		t.Errorf("EnergyWh = %v, want 12345", sample.EnergyWh)
+	}
+}
+
+func TestModbusCarloGavazziInt32LittleEndian(t *testing.T) {
+	holding := map[uint16]uint16{
+		// Low word first: -1234 * 0.1 W is 0xFFFFFB2E
+		0x0012: 0xFB2E, 0x0013: 0xFFFF,
+		0x0000: 2305, 0x0001: 0, // 230.5 V
+		0x000C: 0x86A0, 0x000D: 0x0001, // 100000 * 0.001 A
+		0x002E: 950,           // 0.95
+		0x0034: 42, 0x0035: 0, // 42 * 100 Wh
+	}
+	m := NewModbusPowerMeter(fakeModbusServer(t, registerResponse(holding, nil)), 1, ModbusPresets["carlo-gavazzi"])
+	defer m.Close()
+
+	sample, err := m.GetSample()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(sample.PowerMW-(-123400)) > 1e-6 {
+		t.Errorf("PowerMW = %v, want -123400", sample.PowerMW)
+	}
+	checks := []struct {
+		name string
+		got  *float64
+		want float64
+	}{
+		{"VoltageV", sample.VoltageV, 230.5},
+		{"CurrentA", sample.CurrentA, 100},
+		{"PowerFactor", sample.PowerFactor, 0.95},
+		{"EnergyWh", sample.EnergyWh, 4200},
+	}
+	for _, c := range checks {
+		if c.got == nil || math.Abs(*c.got-c.want) > 1e-6 {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+}
+
+func TestModbusWordOrder(t *testing.T) {
+	hi, lo := float32Words(1500)
+	holding := map[uint16]uint16{100: hi, 101: lo, 200: lo, 201: hi}
+	addr := fakeModbusServer(t, registerResponse(holding, nil))
+
+	for _, reg := range []*ModbusRegister{
+		{Address: 100, Type: "float32"},
+		{Address: 200, Type: "float32", WordOrder: "little"},
+	} {
+		m := NewModbusPowerMeter(addr, 1, ModbusMapping{Power: reg})
+		power, err := m.GetCurrentPower()
+		m.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if power != 1500000 {
+			t.Errorf("word order %q: PowerMW = %v, want 1500000", reg.WordOrder, power)
+		}
+	}
+}
+
+func TestModbusException(t *testing.T) {
+	m := NewModbusPowerMeter(fakeModbusServer(t, registerResponse(nil, nil)), 1, ModbusPresets["sdm"])
+	defer m.Close()
+
+	_, err := m.GetSample()
+	if err == nil || !strings.Contains(err.Error(), "modbus exception 2") {
+		t.Fatalf("err = %v, want modbus exception 2", err)
+	}
+}
+
+func TestModbusTruncatedResponse(t *testing.T) {
+	// An exception function code without the exception code byte
+	addr := fakeModbusServer(t, func(function byte, _, _ uint16) []byte {
+		return []byte{function | 0x80}
+	})
+	m := NewModbusPowerMeter(addr, 1, ModbusPresets["sdm"])
+	defer m.Close()
+
+	if _, err := m.GetSample(); err == nil || !strings.Contains(err.Error(), "malformed") {
+		t.Fatalf("err = %v, want malformed response", err)
+	}
+}
+
+func TestParseModbusRegister(t *testing.T) {
+	reg, err := ParseModbusRegister("holding:18:int32:0.1:little")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ModbusRegister{Address: 18, Type: "int32", Scale: 0.1, WordOrder: "little"}
+	if *reg != want {
+		t.Errorf("got %+v, want %+v", *reg, want)
+	}
+
+	for _, spec := range []string{"input:0x000C", "coil:1:uint16", "input:70000:uint16", "input:1:float64", "input:1:int16:x"} {
+		if _, err := ParseModbusRegister(spec); err == nil {
+			t.Errorf("%q: expected error", spec)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package fritzbox
+
+import (
+	"math"
+	"time"
+)
+
+// energyResolutionWh is the coarsest energy counter resolution of the supported
+// meters. TR-064 and AHA count whole Wh.
+const energyResolutionWh = 1.0
+
+// RefreshDetector detects readings that the device has not refreshed since the
+// previous poll. Slow-updating plugs like the DECT 200 return the same value for
+// several seconds, which would otherwise be counted as independent samples.
+//
+// A sample is considered repeated if its power is identical to the previous one
+// and the meter-side timestamp did not change. Without a timestamp, an unchanged
+// energy counter only marks a reading as repeated once the counter should have
+// advanced by its resolution at the reported power; a stable low-power DUT takes
+// minutes per Wh. Meters without any indicator are never flagged.
+type RefreshDetector struct {
+	last        Sample
+	hasLast     bool
+	energySince time.Time // Poll time of the last energy counter change
+}
+
+// Repeated reports whether sample, polled at the given time, is a repetition
+// of the previous reading and remembers it for the next call.
+func (d *RefreshDetector) Repeated(sample Sample, at time.Time) bool {
+	prev, hadPrev := d.last, d.hasLast
+	d.last, d.hasLast = sample, true
+
+	energyFlat := sample.EnergyWh != nil && hadPrev && prev.EnergyWh != nil && *sample.EnergyWh == *prev.EnergyWh
+	if sample.EnergyWh != nil && !energyFlat {
+		d.energySince = at
+	}
+	if !hadPrev || sample.PowerMW != prev.PowerMW {
+		return false
+	}
+
+	if sample.MeterTimestamp != nil && prev.MeterTimestamp != nil {
+		if !sample.MeterTimestamp.Equal(*prev.MeterTimestamp) {
+			return false
+		}
+		// Same timestamp: repeated unless the counter moved
+		return sample.EnergyWh == nil || prev.EnergyWh == nil || energyFlat
+	}
+	if energyFlat && sample.PowerMW > 0 {
+		return at.Sub(d.energySince) > energyStepDuration(sample.PowerMW)
+	}
+	return false
+}
+
+// energyStepDuration is how long the energy counter takes to advance by its
+// resolution at powerMW
+func energyStepDuration(powerMW float64) time.Duration {
+	seconds := energyResolutionWh * 3600 / (powerMW / 1000)
+	if seconds > math.MaxInt64/float64(time.Second) {
+		return math.MaxInt64
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// Reset forgets the previous reading
+func (d *RefreshDetector) Reset() {
+	d.last, d.hasLast = Sample{}, false
+	d.energySince = time.Time{}
+}
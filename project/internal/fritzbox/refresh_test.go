@@ -0,0 +1,70 @@
+package fritzbox
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRefreshDetectorStableDUT(t *testing.T) {
+	// A flat 1850 mW DUT on TR-064: the whole-Wh counter advances every ~32 min
+	var d RefreshDetector
+	start := time.Now()
+	for i := 0; i < 600; i++ {
+		sample := Sample{PowerMW: 1850, EnergyWh: floatPtr(120)}
+		if d.Repeated(sample, start.Add(time.Duration(i)*time.Second)) {
+			t.Fatalf("stable reading %d flagged as repeated", i)
+		}
+	}
+}
+
+func TestRefreshDetectorStuckCounter(t *testing.T) {
+	// At 100 W the counter must advance every 36 s, a flat counter for longer is stale
+	var d RefreshDetector
+	start := time.Now()
+	sample := Sample{PowerMW: 100000, EnergyWh: floatPtr(50)}
+	if d.Repeated(sample, start) {
+		t.Fatal("first reading flagged as repeated")
+	}
+	if d.Repeated(sample, start.Add(30*time.Second)) {
+		t.Fatal("reading within the counter resolution flagged as repeated")
+	}
+	if !d.Repeated(sample, start.Add(40*time.Second)) {
+		t.Fatal("reading with a stuck counter not flagged as repeated")
+	}
+	if d.Repeated(Sample{PowerMW: 100000, EnergyWh: floatPtr(51)}, start.Add(41*time.Second)) {
+		t.Fatal("reading with an advanced counter flagged as repeated")
+	}
+}
+
+func TestRefreshDetectorTimestamp(t *testing.T) {
+	var d RefreshDetector
+	now := time.Now()
+	ts := now.Add(-time.Second)
+	later := now
+
+	tests := []struct {
+		name   string
+		sample Sample
+		want   bool
+	}{
+		{"first", Sample{PowerMW: 5000, MeterTimestamp: &ts}, false},
+		{"same timestamp", Sample{PowerMW: 5000, MeterTimestamp: &ts}, true},
+		{"new timestamp", Sample{PowerMW: 5000, MeterTimestamp: &later}, false},
+		{"changed power", Sample{PowerMW: 5100, MeterTimestamp: &later}, false},
+	}
+	for i, tt := range tests {
+		if got := d.Repeated(tt.sample, now.Add(time.Duration(i)*time.Second)); got != tt.want {
+			t.Errorf("%s: Repeated = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRefreshDetectorNoIndicators(t *testing.T) {
+	var d RefreshDetector
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		if d.Repeated(Sample{PowerMW: 3000}, now.Add(time.Duration(i)*time.Second)) {
+			t.Fatal("meter without refresh indicators flagged")
+		}
+	}
+}
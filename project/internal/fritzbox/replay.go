@@ -0,0 +1,219 @@
+package fritzbox
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"project/internal/database"
+)
+
+// replayPoint is one recorded power reading
+type replayPoint struct {
+	Offset    time.Duration // Time since the first recorded point
+	Timestamp time.Time     // Original timestamp
+	PowerMW   float64
+	EnergyWh  *float64
+}
+
+// ReplayPowerMeter plays back the PowerMW series of a saved test in real or
+// accelerated time. Playback starts with the first reading; the recorded value
+// is held until the next recorded point is due, like a real meter.
+type ReplayPowerMeter struct {
+	Speed float64 // Playback speed (1 = real time, 10 = ten times faster)
+	Loop  bool    // Restart at the beginning after the last point (otherwise hold it)
+
+	mu     sync.Mutex
+	points []replayPoint
+	start  time.Time
+}
+
+// NewReplayPowerMeterFromCSV loads a test exported as CSV (testing/saved_tests/*.csv)
+func NewReplayPowerMeterFromCSV(path string, speed float64) (*ReplayPowerMeter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replay file: %w", err)
+	}
+	defer f.Close()
+
+	points, err := readReplayCSV(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	fmt.Printf("Initializing ReplayPowerMeter with %d points from %s (speed %.1fx)\n", len(points), path, speed)
+	return newReplayPowerMeter(points, speed)
+}
+
+// NewReplayPowerMeterFromRecord loads a test saved in the database
+func NewReplayPowerMeterFromRecord(record *database.TestRecord, speed float64) (*ReplayPowerMeter, error) {
+	var data []struct {
+		Timestamp time.Time `json:"timestamp"`
+		PowerMW   float64   `json:"power_mw"`
+		EnergyWh  *float64  `json:"energy_wh"`
+		Quality   string    `json:"quality"`
+		Repeated  bool      `json:"repeated"`
+	}
+	if err := json.Unmarshal([]byte(record.Data), &data); err != nil {
+		return nil, fmt.Errorf("failed to parse data of test %d: %w", record.ID, err)
+	}
+
+	points := make([]replayPoint, 0, len(data))
+	for _, dp := range data {
+		if !measuredQuality(dp.Quality) || dp.Repeated {
+			continue
+		}
+		points = append(points, replayPoint{Timestamp: dp.Timestamp, PowerMW: dp.PowerMW, EnergyWh: dp.EnergyWh})
+	}
+	for i := range points {
+		points[i].Offset = points[i].Timestamp.Sub(points[0].Timestamp)
+	}
+
+	fmt.Printf("Initializing ReplayPowerMeter with %d points from test %d '%s' (speed %.1fx)\n",
+		len(points), record.ID, record.TestName, speed)
+	return newReplayPowerMeter(points, speed)
+}
+
+func newReplayPowerMeter(points []replayPoint, speed float64) (*ReplayPowerMeter, error) {
+	if len(points) == 0 {
+		return nil, fmt.Errorf("replay source contains no data points")
+	}
+	if speed <= 0 {
+		speed = 1
+	}
+	sort.SliceStable(points, func(i, j int) bool { return points[i].Offset < points[j].Offset })
+
+	return &ReplayPowerMeter{
+		Speed:  speed,
+		points: points,
+	}, nil
+}
+
+func (r *ReplayPowerMeter) GetCurrentPower() (float64, error) {
+	sample, err := r.GetSample()
+	if err != nil {
+		return 0, err
+	}
+	return sample.PowerMW, nil
+}
+
+// GetSample returns the recorded point due at the current playback position
+func (r *ReplayPowerMeter) GetSample() (Sample, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.start.IsZero() {
+		r.start = time.Now()
+	}
+	pos := time.Duration(float64(time.Since(r.start)) * r.Speed)
+
+	total := r.points[len(r.points)-1].Offset
+	if r.Loop && total > 0 {
+		pos %= total
+	}
+
+	// Last point whose offset is not in the future
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i].Offset > pos }) - 1
+	if i < 0 {
+		i = 0
+	}
+
+	p := r.points[i]
+	ts := p.Timestamp
+	return Sample{
+		PowerMW:        p.PowerMW,
+		EnergyWh:       p.EnergyWh,
+		MeterTimestamp: &ts,
+	}, nil
+}
+
+func (r *ReplayPowerMeter) TestConnection() error {
+	return nil
+}
+
+// Reset restarts playback from the beginning on the next reading
+func (r *ReplayPowerMeter) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.start = time.Time{}
+}
+
+// readReplayCSV parses the CSV export: '#' comment header, then a header row
+// containing at least Timestamp and PowerMW (ElapsedSeconds is used if present).
+func readReplayCSV(in io.Reader) ([]replayPoint, error) {
+	reader := csv.NewReader(in)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("missing header: %w", err)
+	}
+	cols := make(map[string]int)
+	for i, name := range header {
+		cols[strings.TrimSpace(name)] = i
+	}
+	tsCol, hasTS := cols["Timestamp"]
+	powerCol, hasPower := cols["PowerMW"]
+	elapsedCol, hasElapsed := cols["ElapsedSeconds"]
+	qualityCol, hasQuality := cols["Quality"]
+	repeatedCol, hasRepeated := cols["Repeated"]
+	if !hasPower || (!hasTS && !hasElapsed) {
+		return nil, fmt.Errorf("header needs PowerMW and Timestamp or ElapsedSeconds columns")
+	}
+
+	var points []replayPoint
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rec) <= powerCol {
+			continue
+		}
+
+		if hasQuality && qualityCol < len(rec) && !measuredQuality(rec[qualityCol]) {
+			continue
+		}
+		if hasRepeated && repeatedCol < len(rec) && rec[repeatedCol] == "true" {
+			continue
+		}
+
+		line, _ := reader.FieldPos(powerCol)
+		var p replayPoint
+		if p.PowerMW, err = strconv.ParseFloat(rec[powerCol], 64); err != nil {
+			return nil, fmt.Errorf("line %d: invalid PowerMW %q", line, rec[powerCol])
+		}
+		if hasTS && tsCol < len(rec) {
+			p.Timestamp, _ = time.Parse(time.RFC3339Nano, rec[tsCol])
+		}
+		if hasElapsed && elapsedCol < len(rec) {
+			secs, err := strconv.ParseFloat(rec[elapsedCol], 64)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid ElapsedSeconds %q", line, rec[elapsedCol])
+			}
+			p.Offset = time.Duration(secs * float64(time.Second))
+		} else if len(points) > 0 {
+			p.Offset = p.Timestamp.Sub(points[0].Timestamp)
+		}
+		points = append(points, p)
+	}
+
+	return points, nil
+}
+
+// measuredQuality reports whether a recorded quality flag marks an actual reading
+// (stale and missing ticks hold no new value and are skipped, as are repeated ones)
+func measuredQuality(quality string) bool {
+	return quality == "" || quality == "ok" || quality == "retried"
+}
@@ -0,0 +1,55 @@
+package fritzbox
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplayPlayback(t *testing.T) {
+	points, err := readReplayCSV(strings.NewReader("# Test: replay\n" +
+		"Timestamp,ElapsedSeconds,PowerMW,Quality\n" +
+		"2026-10-16T10:00:00Z,0,1000,ok\n" +
+		"2026-10-16T11:00:00Z,3600,2000,ok\n" +
+		"2026-10-16T11:30:00Z,5400,9999,missing\n" +
+		"2026-10-16T12:00:00Z,7200,3000,ok\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := newReplayPowerMeter(points, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// at plays back from a start that lies elapsed in the past
+	at := func(elapsed time.Duration) float64 {
+		t.Helper()
+		m.mu.Lock()
+		m.start = time.Now().Add(-elapsed)
+		m.mu.Unlock()
+		power, err := m.GetCurrentPower()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return power
+	}
+
+	// Missing readings are skipped, the last point is held
+	if power := at(90 * time.Minute); power != 2000 {
+		t.Errorf("after 1h30m: PowerMW = %v, want 2000", power)
+	}
+	if power := at(150 * time.Minute); power != 3000 {
+		t.Errorf("after the end: PowerMW = %v, want 3000", power)
+	}
+
+	m.Loop = true
+	if power := at(150 * time.Minute); power != 1000 {
+		t.Errorf("looped: PowerMW = %v, want 1000", power)
+	}
+
+	at(90 * time.Minute)
+	m.Reset()
+	if power, _ := m.GetCurrentPower(); power != 1000 {
+		t.Errorf("after Reset: PowerMW = %v, want 1000", power)
+	}
+}
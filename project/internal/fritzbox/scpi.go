@@ -0,0 +1,241 @@
+package fritzbox
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SCPICommands holds the query templates for a SCPI instrument.
+// "{ch}" in a template is replaced with the configured channel.
+// Empty queries are skipped; without a power query, power is computed from voltage * current.
+type SCPICommands struct {
+	Init        []string // Sent once after connecting (e.g. to configure numeric items)
+	Power       string   // Returns W
+	Voltage     string   // Returns V
+	Current     string   // Returns A
+	PowerFactor string
+	Energy      string // Returns Wh
+}
+
+// SCPIPresets contains command sets for common bench power analyzers and supplies
+var SCPIPresets = map[string]SCPICommands{
+	// Generic SCPI-99 power analyzer
+	"scpi": {
+		Power:   "MEAS:POW? (@{ch})",
+		Voltage: "MEAS:VOLT? (@{ch})",
+		Current: "MEAS:CURR? (@{ch})",
+	},
+	// Yokogawa WT series: numeric items 1..5 are configured on connect
+	"yokogawa": {
+		Init: []string{
+			":NUMeric:FORMat ASCii",
+			":NUMeric:NORMal:ITEM1 P,{ch}",
+			":NUMeric:NORMal:ITEM2 U,{ch}",
+			":NUMeric:NORMal:ITEM3 I,{ch}",
+			":NUMeric:NORMal:ITEM4 LAMBda,{ch}",
+			":NUMeric:NORMal:ITEM5 WP,{ch}",
+		},
+		Power:       ":NUMeric:NORMal:VALue? 1",
+		Voltage:     ":NUMeric:NORMal:VALue? 2",
+		Current:     ":NUMeric:NORMal:VALue? 3",
+		PowerFactor: ":NUMeric:NORMal:VALue? 4",
+		Energy:      ":NUMeric:NORMal:VALue? 5",
+	},
+	// Programmable DC supply feeding the DUT, power = V * I
+	"supply": {
+		Voltage: "MEAS:VOLT? (@{ch})",
+		Current: "MEAS:CURR? (@{ch})",
+	},
+}
+
+// SCPIPowerMeter reads a bench power analyzer or supply over a raw TCP socket (LXI port 5025)
+type SCPIPowerMeter struct {
+	Address  string // host:port
+	Channel  int
+	Commands SCPICommands
+	Timeout  time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func NewSCPIPowerMeter(address string, channel int, commands SCPICommands) *SCPIPowerMeter {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "5025")
+	}
+	if channel == 0 {
+		channel = 1
+	}
+
+	fmt.Printf("Initializing SCPIPowerMeter with Address: %s, Channel: %d\n", address, channel)
+
+	return &SCPIPowerMeter{
+		Address:  address,
+		Channel:  channel,
+		Commands: commands,
+		Timeout:  5 * time.Second,
+	}
+}
+
+func (s *SCPIPowerMeter) GetCurrentPower() (float64, error) {
+	sample, err := s.GetSample()
+	if err != nil {
+		return 0, err
+	}
+	return sample.PowerMW, nil
+}
+
+// GetSample queries all configured quantities
+func (s *SCPIPowerMeter) GetSample() (Sample, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var sample Sample
+	optional := []struct {
+		cmd string
+		dst **float64
+	}{
+		{s.Commands.Voltage, &sample.VoltageV},
+		{s.Commands.Current, &sample.CurrentA},
+		{s.Commands.PowerFactor, &sample.PowerFactor},
+		{s.Commands.Energy, &sample.EnergyWh},
+	}
+	for _, o := range optional {
+		if o.cmd == "" {
+			continue
+		}
+		v, err := s.queryFloat(o.cmd)
+		if err != nil {
+			return Sample{}, err
+		}
+		*o.dst = floatPtr(v)
+	}
+
+	switch {
+	case s.Commands.Power != "":
+		watts, err := s.queryFloat(s.Commands.Power)
+		if err != nil {
+			return Sample{}, err
+		}
+		sample.PowerMW = watts * 1000
+	case sample.VoltageV != nil && sample.CurrentA != nil:
+		sample.PowerMW = *sample.VoltageV * *sample.CurrentA * 1000
+	default:
+		return Sample{}, fmt.Errorf("no SCPI power query configured")
+	}
+
+	now := time.Now()
+	sample.MeterTimestamp = &now
+	return sample, nil
+}
+
+func (s *SCPIPowerMeter) TestConnection() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idn, err := s.query("*IDN?")
+	if err != nil {
+		return err
+	}
+	fmt.Printf("SCPI instrument: %s\n", idn)
+	return nil
+}
+
+// Close closes the instrument connection
+func (s *SCPIPowerMeter) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.disconnect()
+}
+
+// connect opens the socket and sends the init commands (caller holds mu)
+func (s *SCPIPowerMeter) connect() error {
+	conn, err := net.DialTimeout("tcp", s.Address, s.Timeout)
+	if err != nil {
+		return fmt.Errorf("failed to connect to SCPI instrument %s: %w", s.Address, err)
+	}
+	s.conn = conn
+	s.reader = bufio.NewReader(conn)
+
+	for _, cmd := range s.Commands.Init {
+		if err := s.write(s.expand(cmd)); err != nil {
+			s.disconnect()
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SCPIPowerMeter) disconnect() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	s.reader = nil
+	return err
+}
+
+func (s *SCPIPowerMeter) write(cmd string) error {
+	s.conn.SetDeadline(time.Now().Add(s.Timeout))
+	if _, err := s.conn.Write([]byte(cmd + "\n")); err != nil {
+		return fmt.Errorf("SCPI write %q failed: %w", cmd, err)
+	}
+	return nil
+}
+
+// query sends a query and returns the response line. On I/O errors the
+// connection is dropped so the next call reconnects.
+func (s *SCPIPowerMeter) query(cmd string) (string, error) {
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return "", err
+		}
+	}
+
+	cmd = s.expand(cmd)
+	if err := s.write(cmd); err != nil {
+		s.disconnect()
+		return "", err
+	}
+	line, err := s.reader.ReadString('\n')
+	if err != nil {
+		s.disconnect()
+		return "", fmt.Errorf("SCPI query %q failed: %w", cmd, err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// queryFloat sends a query and parses the first value of the (comma-separated) response
+func (s *SCPIPowerMeter) queryFloat(cmd string) (float64, error) {
+	resp, err := s.query(cmd)
+	if err != nil {
+		return 0, err
+	}
+
+	field := strings.TrimSpace(strings.Split(resp, ",")[0])
+	// Some instruments prefix the value with a header, e.g. ":NUM:NORM:VAL 1.23E+00"
+	if i := strings.LastIndexByte(field, ' '); i >= 0 {
+		field = field[i+1:]
+	}
+	v, err := strconv.ParseFloat(field, 64)
+	if err != nil {
+		return 0, fmt.Errorf("SCPI query %q returned non-numeric value %q", cmd, resp)
+	}
+	// 9.91E+37 is the SCPI "not a number" marker, some instruments send NAN/INF instead
+	if math.IsNaN(v) || math.IsInf(v, 0) || math.Abs(v) >= 9.9e37 {
+		return 0, fmt.Errorf("SCPI query %q returned no valid data", cmd)
+	}
+	return v, nil
+}
+
+func (s *SCPIPowerMeter) expand(cmd string) string {
+	return strings.ReplaceAll(cmd, "{ch}", strconv.Itoa(s.Channel))
+}
@@ -0,0 +1,249 @@
+package fritzbox
+
+import (
+	"bufio"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSCPIServer answers queries with the configured responses. Queries
+// without a response are left unanswered, commands are only recorded.
+type fakeSCPIServer struct {
+	ln        net.Listener
+	responses map[string]string
+
+	mu       sync.Mutex
+	received []string
+	conns    []net.Conn
+}
+
+func newFakeSCPIServer(t *testing.T, responses map[string]string) *fakeSCPIServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeSCPIServer{ln: ln, responses: responses}
+	t.Cleanup(func() {
+		ln.Close()
+		f.dropConnections()
+	})
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.conns = append(f.conns, conn)
+			f.mu.Unlock()
+			go f.serve(conn)
+		}
+	}()
+	return f
+}
+
+func (f *fakeSCPIServer) serve(conn net.Conn) {
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		cmd := scanner.Text()
+		f.mu.Lock()
+		f.received = append(f.received, cmd)
+		f.mu.Unlock()
+		if resp, ok := f.responses[cmd]; ok {
+			if _, err := conn.Write([]byte(resp + "\n")); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// dropConnections closes all open connections, like an instrument being reset
+func (f *fakeSCPIServer) dropConnections() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, conn := range f.conns {
+		conn.Close()
+	}
+	f.conns = nil
+}
+
+func (f *fakeSCPIServer) commands() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return slices.Clone(f.received)
+}
+
+func (f *fakeSCPIServer) connections() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.conns)
+}
+
+func TestSCPIIdentify(t *testing.T) {
+	f := newFakeSCPIServer(t, map[string]string{"*IDN?": "KEYSIGHT,N6705C,MY12345678,D.03.01"})
+	m := NewSCPIPowerMeter(f.ln.Addr().String(), 0, SCPIPresets["scpi"])
+	defer m.Close()
+
+	if err := m.TestConnection(); err != nil {
+		t.Fatal(err)
+	}
+	if m.Channel != 1 {
+		t.Errorf("Channel = %d, want default 1", m.Channel)
+	}
+}
+
+func TestSCPIPresetGeneric(t *testing.T) {
+	f := newFakeSCPIServer(t, map[string]string{
+		"MEAS:POW? (@2)":  "+4.567E+00",
+		"MEAS:VOLT? (@2)": "+2.30E+02",
+		"MEAS:CURR? (@2)": "+1.98E-02,+1.99E-02", // Only the first value counts
+	})
+	m := NewSCPIPowerMeter(f.ln.Addr().String(), 2, SCPIPresets["scpi"])
+	defer m.Close()
+
+	sample, err := m.GetSample()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sample.PowerMW != 4567 {
+		t.Errorf("PowerMW = %v, want 4567", sample.PowerMW)
+	}
+	if sample.VoltageV == nil || *sample.VoltageV != 230 || sample.CurrentA == nil || *sample.CurrentA != 0.0198 {
+		t.Errorf("VoltageV = %v, CurrentA = %v", sample.VoltageV, sample.CurrentA)
+	}
+	if sample.MeterTimestamp == nil {
+		t.Error("MeterTimestamp not set")
+	}
+}
+
+func TestSCPIPresetYokogawa(t *testing.T) {
+	f := newFakeSCPIServer(t, map[string]string{
+		":NUMeric:NORMal:VALue? 1": ":NUM:NORM:VAL 1.2345E+01",
+		":NUMeric:NORMal:VALue? 2": "2.2950E+02",
+		":NUMeric:NORMal:VALue? 3": "5.3800E-02",
+		":NUMeric:NORMal:VALue? 4": "9.9100E-01",
+		":NUMeric:NORMal:VALue? 5": "1.5000E+00",
+	})
+	m := NewSCPIPowerMeter(f.ln.Addr().String(), 3, SCPIPresets["yokogawa"])
+	defer m.Close()
+
+	sample, err := m.GetSample()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sample.PowerMW != 12345 {
+		t.Errorf("PowerMW = %v, want 12345", sample.PowerMW)
+	}
+	if sample.PowerFactor == nil || *sample.PowerFactor != 0.991 || sample.EnergyWh == nil || *sample.EnergyWh != 1.5 {
+		t.Errorf("PowerFactor = %v, EnergyWh = %v", sample.PowerFactor, sample.EnergyWh)
+	}
+
+	// The numeric items are configured once per connection, for the configured element
+	if _, err := m.GetSample(); err != nil {
+		t.Fatal(err)
+	}
+	cmds := f.commands()
+	init := SCPIPresets["yokogawa"].Init
+	if len(cmds) != len(init)+10 {
+		t.Fatalf("got %d commands, want %d: %q", len(cmds), len(init)+10, cmds)
+	}
+	for i, cmd := range init {
+		if want := strings.ReplaceAll(cmd, "{ch}", "3"); cmds[i] != want {
+			t.Errorf("init command %d = %q, want %q", i, cmds[i], want)
+		}
+	}
+}
+
+func TestSCPIPresetSupply(t *testing.T) {
+	f := newFakeSCPIServer(t, map[string]string{
+		"MEAS:VOLT? (@1)": "12.0",
+		"MEAS:CURR? (@1)": "0.5",
+	})
+	m := NewSCPIPowerMeter(f.ln.Addr().String(), 1, SCPIPresets["supply"])
+	defer m.Close()
+
+	// Without a power query, power is V * I
+	power, err := m.GetCurrentPower()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if power != 6000 {
+		t.Errorf("PowerMW = %v, want 6000", power)
+	}
+}
+
+func TestSCPIInvalidValues(t *testing.T) {
+	f := newFakeSCPIServer(t, map[string]string{
+		"POW? overload": "9.91E+37",
+		"POW? nan":      "NAN",
+		"POW? text":     "OVERLOAD",
+	})
+	for _, query := range []string{"POW? overload", "POW? nan", "POW? text"} {
+		m := NewSCPIPowerMeter(f.ln.Addr().String(), 1, SCPICommands{Power: query})
+		if _, err := m.GetCurrentPower(); err == nil {
+			t.Errorf("%s: expected error", query)
+		}
+		m.Close()
+	}
+}
+
+func TestSCPIReadTimeout(t *testing.T) {
+	f := newFakeSCPIServer(t, map[string]string{"*IDN?": "FAKE,1,2,3"}) // MEAS:POW? is never answered
+	m := NewSCPIPowerMeter(f.ln.Addr().String(), 1, SCPIPresets["scpi"])
+	m.Timeout = 100 * time.Millisecond
+	defer m.Close()
+
+	start := time.Now()
+	if _, err := m.GetCurrentPower(); err == nil {
+		t.Fatal("unanswered query succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("query took %s with a 100ms timeout", elapsed)
+	}
+
+	// A late response to the timed out query must not be read as the next answer
+	if err := m.TestConnection(); err != nil {
+		t.Fatal(err)
+	}
+	if n := f.connections(); n != 2 {
+		t.Errorf("connections = %d, want a reconnect after the timeout", n)
+	}
+}
+
+func TestSCPIReconnect(t *testing.T) {
+	f := newFakeSCPIServer(t, map[string]string{"MEAS:POW? (@1)": "1.5"})
+	m := NewSCPIPowerMeter(f.ln.Addr().String(), 1, SCPICommands{Power: "MEAS:POW? (@{ch})"})
+	defer m.Close()
+
+	if _, err := m.GetCurrentPower(); err != nil {
+		t.Fatal(err)
+	}
+
+	f.dropConnections()
+	// The first query after the drop fails and closes the connection, the next one reconnects
+	var power float64
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if power, err = m.GetCurrentPower(); err == nil {
+			break
+		}
+	}
+	if err != nil {
+		t.Fatalf("no reconnect: %v", err)
+	}
+	if power != 1500 {
+		t.Errorf("PowerMW = %v, want 1500", power)
+	}
+
+	f.ln.Close()
+	f.dropConnections()
+	if _, err := m.GetCurrentPower(); err == nil {
+		t.Error("query succeeded with the instrument gone")
+	}
+}
@@ -0,0 +1,163 @@
+package fritzbox
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShellyGen1(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "admin" || password != "secret" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/meter/1":
+			fmt.Fprint(w, `{"power":12.34,"overpower":0.00,"is_valid":true,"timestamp":1700000000,"counters":[12.3,12.4,12.2],"total":6000}`)
+		case "/meter/2":
+			fmt.Fprint(w, `{"power":0,"is_valid":false,"timestamp":0,"total":0}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	m := NewShellyPowerMeter(srv.URL, 1, 1)
+	if _, err := m.GetSample(); err == nil {
+		t.Fatal("request without credentials succeeded")
+	}
+	m.Username, m.Password = "admin", "secret"
+
+	sample, err := m.GetSample()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(sample.PowerMW-12340) > 1e-9 {
+		t.Errorf("PowerMW = %v, want 12340", sample.PowerMW)
+	}
+	// Total is in watt-minutes
+	if sample.EnergyWh == nil || *sample.EnergyWh != 100 {
+		t.Errorf("EnergyWh = %v, want 100", sample.EnergyWh)
+	}
+	if sample.MeterTimestamp == nil || !sample.MeterTimestamp.Equal(time.Unix(1700000000, 0)) {
+		t.Errorf("MeterTimestamp = %v", sample.MeterTimestamp)
+	}
+
+	m.Channel = 2
+	if _, err := m.GetSample(); err == nil || !strings.Contains(err.Error(), "invalid reading") {
+		t.Errorf("err = %v, want invalid reading", err)
+	}
+}
+
+func TestShellyGen2(t *testing.T) {
+	var switched string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rpc/Switch.GetStatus":
+			switch r.URL.Query().Get("id") {
+			case "0":
+				fmt.Fprint(w, `{"id":0,"source":"HTTP","output":true,"apower":8.9,"voltage":232.1,"current":0.061,"pf":0.62,`+
+					`"aenergy":{"total":1234.567,"by_minute":[148.2,149.1,147.5],"minute_ts":1700000060},"temperature":{"tC":35.2,"tF":95.4}}`)
+			default:
+				// Switch without power metering (e.g. Plus 1)
+				fmt.Fprint(w, `{"id":1,"source":"init","output":false,"temperature":{"tC":30.1,"tF":86.2}}`)
+			}
+		case "/rpc/Switch.Set":
+			switched = r.URL.Query().Get("on")
+			fmt.Fprint(w, `{"was_on":true}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	m := NewShellyPowerMeter(srv.URL, 2, 0)
+	sample, err := m.GetSample()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(sample.PowerMW-8900) > 1e-9 {
+		t.Errorf("PowerMW = %v, want 8900", sample.PowerMW)
+	}
+	checks := []struct {
+		name string
+		got  *float64
+		want float64
+	}{
+		{"VoltageV", sample.VoltageV, 232.1},
+		{"CurrentA", sample.CurrentA, 0.061},
+		{"PowerFactor", sample.PowerFactor, 0.62},
+		{"EnergyWh", sample.EnergyWh, 1234.567},
+	}
+	for _, c := range checks {
+		if c.got == nil || *c.got != c.want {
+			t.Errorf("%s = %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if sample.MeterTimestamp == nil || !sample.MeterTimestamp.Equal(time.Unix(1700000060, 0)) {
+		t.Errorf("MeterTimestamp = %v", sample.MeterTimestamp)
+	}
+
+	if err := m.SetSwitch(false); err != nil || switched != "false" {
+		t.Errorf("SetSwitch(false): err %v, on=%q", err, switched)
+	}
+
+	m.Channel = 1
+	if _, err := m.GetSample(); err == nil || !strings.Contains(err.Error(), "no power metering") {
+		t.Errorf("err = %v, want no power metering", err)
+	}
+}
+
+func TestShellyGen2DigestAuth(t *testing.T) {
+	const realm, nonce = "shellyplugsg3-a0b1c2d3e4f5", "1700000000"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !validShellyDigest(r.Header.Get("Authorization"), realm, nonce, "admin", "secret") {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Digest qop="auth", realm="%s", nonce="%s", algorithm=SHA-256`, realm, nonce))
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"id":0,"apower":42.0}`)
+	}))
+	defer srv.Close()
+
+	m := NewShellyPowerMeter(srv.URL, 2, 0)
+	m.Username, m.Password = "admin", "wrong"
+	if _, err := m.GetSample(); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Fatalf("err = %v, want 401 with the wrong password", err)
+	}
+
+	m.Password = "secret"
+	power, err := m.GetCurrentPower()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if power != 42000 {
+		t.Errorf("PowerMW = %v, want 42000", power)
+	}
+}
+
+// validShellyDigest checks a SHA-256 digest authorization with qop "auth"
+func validShellyDigest(header, realm, nonce, username, password string) bool {
+	if !strings.HasPrefix(header, "Digest ") {
+		return false
+	}
+	p := parseAuthParams(strings.TrimPrefix(header, "Digest "))
+	if p["username"] != username || p["realm"] != realm || p["nonce"] != nonce || p["qop"] != "auth" || p["algorithm"] != "SHA-256" {
+		return false
+	}
+	sha := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	ha1 := sha(username + ":" + realm + ":" + password)
+	ha2 := sha("GET:" + p["uri"])
+	return p["response"] == sha(ha1+":"+nonce+":"+p["nc"]+":"+p["cnonce"]+":auth:"+ha2)
+}
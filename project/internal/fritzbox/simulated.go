@@ -0,0 +1,175 @@
+package fritzbox
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// ThroughputSource provides live per-interface throughput in Mbps.
+// loadgen.LoadGenerator satisfies this interface.
+type ThroughputSource interface {
+	GetThroughputByInterface() map[string]float64
+}
+
+// SimulatedDUTConfig parameterizes the simulated device under test and meter
+type SimulatedDUTConfig struct {
+	IdlePowerMW      float64            // Power without traffic
+	WattsPerGbps     float64            // Additional power per Gbps on each port
+	PortWattsPerGbps map[string]float64 // Per-port override of WattsPerGbps
+	NoiseMW          float64            // Standard deviation of gaussian noise
+	QuantizationMW   float64            // Meter resolution (10 mW for the DECT 200, 0 = none)
+	Lag              time.Duration      // Time constant of the meter's first-order response
+}
+
+// DefaultSimulatedDUTConfig roughly resembles a small router on a DECT 200 plug
+func DefaultSimulatedDUTConfig() SimulatedDUTConfig {
+	return SimulatedDUTConfig{
+		IdlePowerMW:    8000,
+		WattsPerGbps:   0.6,
+		NoiseMW:        30,
+		QuantizationMW: 10,
+		Lag:            5 * time.Second,
+	}
+}
+
+// simulationStep is the internal update rate of the DUT model
+const simulationStep = 100 * time.Millisecond
+
+// SimulatedPowerMeter models DUT power as a function of the live throughput of
+// the load generator, including meter lag, noise and quantization. It gives the
+// ramping and analysis code a realistic end-to-end target without hardware.
+type SimulatedPowerMeter struct {
+	Config SimulatedDUTConfig
+
+	source    ThroughputSource
+	stop      chan struct{}
+	closeOnce sync.Once
+
+	mu         sync.Mutex
+	filteredMW float64 // Power as seen by the meter (after lag)
+	outletOff  bool
+	energyWh   float64
+	lastUpdate time.Time
+}
+
+func NewSimulatedPowerMeter(source ThroughputSource, config SimulatedDUTConfig) *SimulatedPowerMeter {
+	s := &SimulatedPowerMeter{
+		Config:     config,
+		source:     source,
+		stop:       make(chan struct{}),
+		filteredMW: config.IdlePowerMW,
+		lastUpdate: time.Now(),
+	}
+	go s.run()
+	return s
+}
+
+// run advances the model continuously so the lag reflects load changes
+// between reads, until Close is called
+func (s *SimulatedPowerMeter) run() {
+	ticker := time.NewTicker(simulationStep)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.mu.Lock()
+			s.update(time.Now())
+			s.mu.Unlock()
+		}
+	}
+}
+
+// update advances the first-order lag and the energy counter to now (caller holds mu)
+func (s *SimulatedPowerMeter) update(now time.Time) {
+	dt := now.Sub(s.lastUpdate)
+	if dt <= 0 {
+		return
+	}
+	s.lastUpdate = now
+
+	target := s.truePowerMW()
+	if s.Config.Lag > 0 {
+		alpha := 1 - math.Exp(-dt.Seconds()/s.Config.Lag.Seconds())
+		s.filteredMW += (target - s.filteredMW) * alpha
+	} else {
+		s.filteredMW = target
+	}
+
+	s.energyWh += s.filteredMW / 1000 * dt.Hours()
+}
+
+// truePowerMW returns the instantaneous DUT power for the current throughput
+func (s *SimulatedPowerMeter) truePowerMW() float64 {
+	if s.outletOff {
+		return 0
+	}
+
+	power := s.Config.IdlePowerMW
+	if s.source == nil {
+		return power
+	}
+
+	for port, mbps := range s.source.GetThroughputByInterface() {
+		wattsPerGbps := s.Config.WattsPerGbps
+		if w, ok := s.Config.PortWattsPerGbps[port]; ok {
+			wattsPerGbps = w
+		}
+		power += wattsPerGbps * mbps // W/Gbps * Mbps = mW
+	}
+	return power
+}
+
+func (s *SimulatedPowerMeter) GetCurrentPower() (float64, error) {
+	sample, err := s.GetSample()
+	if err != nil {
+		return 0, err
+	}
+	return sample.PowerMW, nil
+}
+
+// GetSample returns the lagged, noisy and quantized power plus the simulated energy counter
+func (s *SimulatedPowerMeter) GetSample() (Sample, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	s.update(now)
+
+	power := s.filteredMW + rand.NormFloat64()*s.Config.NoiseMW
+	if q := s.Config.QuantizationMW; q > 0 {
+		power = math.Round(power/q) * q
+	}
+	if power < 0 {
+		power = 0
+	}
+
+	return Sample{
+		PowerMW:        power,
+		EnergyWh:       floatPtr(s.energyWh),
+		MeterTimestamp: &now,
+	}, nil
+}
+
+// SetSwitch switches the simulated outlet; the DUT draws no power while it is off
+func (s *SimulatedPowerMeter) SetSwitch(on bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.update(time.Now())
+	s.outletOff = !on
+	return nil
+}
+
+func (s *SimulatedPowerMeter) TestConnection() error {
+	return nil
+}
+
+// Close stops the background updates of the model
+func (s *SimulatedPowerMeter) Close() error {
+	s.closeOnce.Do(func() { close(s.stop) })
+	return nil
+}
@@ -0,0 +1,86 @@
+package fritzbox
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fixedThroughput is a load generator stand-in with settable throughput
+type fixedThroughput struct {
+	mu   sync.Mutex
+	mbps map[string]float64
+}
+
+func (f *fixedThroughput) set(mbps map[string]float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mbps = mbps
+}
+
+func (f *fixedThroughput) GetThroughputByInterface() map[string]float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.mbps
+}
+
+func TestSimulatedPowerModel(t *testing.T) {
+	source := &fixedThroughput{}
+	m := NewSimulatedPowerMeter(source, SimulatedDUTConfig{
+		IdlePowerMW:      8000,
+		WattsPerGbps:     0.6,
+		PortWattsPerGbps: map[string]float64{"eth2": 1.5},
+		QuantizationMW:   10,
+	})
+	defer m.Close()
+
+	if power, _ := m.GetCurrentPower(); power != 8000 {
+		t.Errorf("idle PowerMW = %v, want 8000", power)
+	}
+
+	// 0.6 W/Gbps * 940 Mbps + 1.5 W/Gbps * 500 Mbps = 564 + 750 mW, quantized to 10 mW
+	source.set(map[string]float64{"eth1": 940, "eth2": 500})
+	if power, _ := m.GetCurrentPower(); power != 9310 {
+		t.Errorf("load PowerMW = %v, want 9310", power)
+	}
+
+	if err := m.SetSwitch(false); err != nil {
+		t.Fatal(err)
+	}
+	if power, _ := m.GetCurrentPower(); power != 0 {
+		t.Errorf("outlet off PowerMW = %v, want 0", power)
+	}
+}
+
+func TestSimulatedPowerLag(t *testing.T) {
+	source := &fixedThroughput{}
+	m := NewSimulatedPowerMeter(source, SimulatedDUTConfig{IdlePowerMW: 8000, WattsPerGbps: 1, Lag: time.Hour})
+	defer m.Close()
+
+	source.set(map[string]float64{"eth1": 1000})
+	if power, _ := m.GetCurrentPower(); power < 8000 || power > 8001 {
+		t.Errorf("PowerMW = %v, want the meter to lag behind the step to 9000", power)
+	}
+}
+
+func TestSimulatedPowerClose(t *testing.T) {
+	m := NewSimulatedPowerMeter(nil, DefaultSimulatedDUTConfig())
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Close(); err != nil {
+		t.Fatalf("second Close: %v", err)
+	}
+
+	// Only reads advance the model once the background updates stopped
+	time.Sleep(simulationStep)
+	m.mu.Lock()
+	stopped := m.lastUpdate
+	m.mu.Unlock()
+	time.Sleep(3 * simulationStep)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.lastUpdate.Equal(stopped) {
+		t.Error("model still updated after Close")
+	}
+}
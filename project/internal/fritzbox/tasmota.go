@@ -0,0 +1,114 @@
+package fritzbox
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// TasmotaPowerMeter reads a Tasmota-flashed plug via the "Status 8" command
+type TasmotaPowerMeter struct {
+	BaseURL  string
+	Username string // Optional web password (passed as user/password query parameters)
+	Password string
+	Client   *http.Client
+}
+
+func NewTasmotaPowerMeter(baseURL string) *TasmotaPowerMeter {
+	baseURL = strings.TrimRight(baseURL, "/")
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+
+	fmt.Printf("Initializing TasmotaPowerMeter with URL: %s\n", baseURL)
+
+	return &TasmotaPowerMeter{
+		BaseURL: baseURL,
+		Client:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (t *TasmotaPowerMeter) GetCurrentPower() (float64, error) {
+	sample, err := t.GetSample()
+	if err != nil {
+		return 0, err
+	}
+	return sample.PowerMW, nil
+}
+
+// GetSample returns power, voltage, current, power factor and the total energy counter
+func (t *TasmotaPowerMeter) GetSample() (Sample, error) {
+	q := url.Values{}
+	q.Set("cmnd", "Status 8")
+	if t.Username != "" {
+		q.Set("user", t.Username)
+		q.Set("password", t.Password)
+	}
+
+	var status struct {
+		StatusSNS struct {
+			Time   string `json:"Time"`
+			Energy *struct {
+				Total   float64  `json:"Total"` // kWh
+				Power   float64  `json:"Power"` // W
+				Factor  *float64 `json:"Factor"`
+				Voltage *float64 `json:"Voltage"`
+				Current *float64 `json:"Current"`
+			} `json:"ENERGY"`
+		} `json:"StatusSNS"`
+	}
+	if err := getJSON(t.Client, t.BaseURL+"/cm?"+q.Encode(), "", "", &status); err != nil {
+		return Sample{}, err
+	}
+
+	energy := status.StatusSNS.Energy
+	if energy == nil {
+		return Sample{}, fmt.Errorf("tasmota device has no energy sensor")
+	}
+
+	sample := Sample{
+		PowerMW:     energy.Power * 1000,
+		VoltageV:    energy.Voltage,
+		CurrentA:    energy.Current,
+		PowerFactor: energy.Factor,
+		EnergyWh:    floatPtr(energy.Total * 1000),
+	}
+	// Tasmota reports local device time without a zone
+	if ts, err := time.ParseInLocation("2006-01-02T15:04:05", status.StatusSNS.Time, time.Local); err == nil {
+		sample.MeterTimestamp = &ts
+	}
+	return sample, nil
+}
+
+// SetSwitch switches the relay with the Power command
+func (t *TasmotaPowerMeter) SetSwitch(on bool) error {
+	state := "OFF"
+	if on {
+		state = "ON"
+	}
+
+	q := url.Values{}
+	q.Set("cmnd", "Power "+state)
+	if t.Username != "" {
+		q.Set("user", t.Username)
+		q.Set("password", t.Password)
+	}
+
+	var resp struct {
+		Power string `json:"POWER"`
+	}
+	if err := getJSON(t.Client, t.BaseURL+"/cm?"+q.Encode(), "", "", &resp); err != nil {
+		return err
+	}
+	if resp.Power != state {
+		return fmt.Errorf("tasmota reports power %q after switching %s", resp.Power, state)
+	}
+	return nil
+}
+
+func (t *TasmotaPowerMeter) TestConnection() error {
+	_, err := t.GetSample()
+	return err
+}
@@ -0,0 +1,76 @@
+package fritzbox
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTasmotaStatus8(t *testing.T) {
+	power := "OFF"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if r.URL.Path != "/cm" || q.Get("user") != "admin" || q.Get("password") != "secret" {
+			fmt.Fprint(w, `{"WARNING":"Need user=<username>&password=<password>"}`)
+			return
+		}
+		switch q.Get("cmnd") {
+		case "Status 8":
+			fmt.Fprint(w, `{"StatusSNS":{"Time":"2026-10-16T14:05:00","ENERGY":{"TotalStartTime":"2026-01-01T00:00:00",`+
+				`"Total":1.234,"Yesterday":0.120,"Today":0.045,"Power":17,"ApparentPower":25,"ReactivePower":18,`+
+				`"Factor":0.68,"Voltage":229,"Current":0.109}}}`)
+		case "Power ON", "Power OFF":
+			power = strings.TrimPrefix(q.Get("cmnd"), "Power ")
+			fmt.Fprintf(w, `{"POWER":"%s"}`, power)
+		default:
+			fmt.Fprint(w, `{"Command":"Unknown"}`)
+		}
+	}))
+	defer srv.Close()
+
+	m := NewTasmotaPowerMeter(srv.URL)
+	if _, err := m.GetSample(); err == nil || !strings.Contains(err.Error(), "no energy sensor") {
+		t.Fatalf("err = %v, want no energy sensor without credentials", err)
+	}
+	m.Username, m.Password = "admin", "secret"
+
+	sample, err := m.GetSample()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sample.PowerMW != 17000 {
+		t.Errorf("PowerMW = %v, want 17000", sample.PowerMW)
+	}
+	// Total is in kWh
+	if sample.EnergyWh == nil || *sample.EnergyWh != 1234 {
+		t.Errorf("EnergyWh = %v, want 1234", sample.EnergyWh)
+	}
+	if sample.VoltageV == nil || *sample.VoltageV != 229 || sample.CurrentA == nil || *sample.CurrentA != 0.109 {
+		t.Errorf("VoltageV = %v, CurrentA = %v", sample.VoltageV, sample.CurrentA)
+	}
+	if sample.PowerFactor == nil || *sample.PowerFactor != 0.68 {
+		t.Errorf("PowerFactor = %v, want 0.68", sample.PowerFactor)
+	}
+	want := time.Date(2026, 10, 16, 14, 5, 0, 0, time.Local)
+	if sample.MeterTimestamp == nil || !sample.MeterTimestamp.Equal(want) {
+		t.Errorf("MeterTimestamp = %v, want %v", sample.MeterTimestamp, want)
+	}
+
+	if err := m.SetSwitch(true); err != nil || power != "ON" {
+		t.Errorf("SetSwitch(true): err %v, power %s", err, power)
+	}
+}
+
+func TestTasmotaSwitchMismatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"POWER":"OFF"}`) // Relay locked by a rule
+	}))
+	defer srv.Close()
+
+	if err := NewTasmotaPowerMeter(srv.URL).SetSwitch(true); err == nil {
+		t.Fatal("switch mismatch not reported")
+	}
+}
@@ -179,9 +179,13 @@ func (lg *NetworkLoadGenerator) StartLayer2(ctx context.Context, config Config)
 		lg.layer2Gen.interfaceThroughput[ifaceConfig.Name] = &InterfaceThroughput{}
 		lg.layer2Gen.targetThroughput[ifaceConfig.Name] = ifaceConfig.TargetThroughput
 		lg.layer2Gen.stopChans[ifaceConfig.Name] = make(chan struct{})
-		// Initialize atomic counters for this interface
+		// Initialize atomic counters for this interface. The byte counter of a
+		// restarted interface is kept for the throughput samplers.
 		var byteCounter uint64 = 0
 		var packetCounter uint64 = 0
+		if old, exists := lg.layer2Gen.interfaceBytesSent[ifaceConfig.Name]; exists {
+			byteCounter = atomic.LoadUint64(old)
+		}
 		lg.layer2Gen.interfaceBytesSent[ifaceConfig.Name] = &byteCounter
 		lg.layer2Gen.interfacePacketsSent[ifaceConfig.Name] = &packetCounter
 		lg.layer2Gen.mu.Unlock()
@@ -297,6 +301,11 @@ func (lg *NetworkLoadGenerator) layer2Worker(ctx context.Context, ifaceConfig In
 			// Fast path: just continue sending
 		}
 
+		if lg.paused.Load() {
+			PreciseSleep(pausePollInterval)
+			continue
+		}
+
 		// Send burst of packets in tight loop
 		burstBytes = 0
 		burstPackets = 0
@@ -351,6 +360,14 @@ func (lg *NetworkLoadGenerator) updateLayer2Throughput(ctx context.Context, ifac
 	for {
 		select {
 		case <-ctx.Done():
+			// Report 0 once the interface stops sending
+			lg.layer2Gen.mu.Lock()
+			if ifaceTput, ok := lg.layer2Gen.interfaceThroughput[ifaceName]; ok {
+				ifaceTput.mu.Lock()
+				ifaceTput.Mbps = 0
+				ifaceTput.mu.Unlock()
+			}
+			lg.layer2Gen.mu.Unlock()
 			return
 		case <-ticker.C:
 			// Calculate throughput from accumulated bytes
@@ -421,6 +438,22 @@ func (lg *NetworkLoadGenerator) GetLayer2ThroughputByInterface() map[string]floa
 	return result
 }
 
+// GetLayer2BytesSentByInterface returns the cumulative bytes sent per interface
+func (lg *NetworkLoadGenerator) GetLayer2BytesSentByInterface() map[string]uint64 {
+	if lg.layer2Gen == nil {
+		return nil
+	}
+
+	lg.layer2Gen.mu.RLock()
+	defer lg.layer2Gen.mu.RUnlock()
+
+	result := make(map[string]uint64, len(lg.layer2Gen.interfaceBytesSent))
+	for ifaceName, counter := range lg.layer2Gen.interfaceBytesSent {
+		result[ifaceName] = atomic.LoadUint64(counter)
+	}
+	return result
+}
+
 // StopLayer2 stops Layer 2 load generation
 func (lg *NetworkLoadGenerator) StopLayer2() {
 	if lg.layer2Gen == nil {
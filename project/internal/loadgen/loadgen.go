@@ -7,6 +7,7 @@ import (
 	"log"
 	"net"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -35,9 +36,12 @@ type LoadGenerator interface {
 	GetThroughput() float64                            // Returns total throughput in Mbps
 	GetThroughputByInterface() map[string]float64      // Returns throughput per interface
 	GetTargetThroughputByInterface() map[string]float64 // Returns target throughput per interface
+	GetBytesSentByInterface() map[string]uint64        // Returns cumulative bytes sent per interface
 	SetTargetThroughput(mbps float64)                  // Set target throughput for rate limiting (global)
 	SetInterfaceTargetThroughput(ifaceName string, mbps float64) // Set target for specific interface
 	GetTargetThroughput() float64                      // Get current target throughput
+	SetPaused(paused bool)                             // Pause or resume sending without stopping the workers
+	IsPaused() bool
 }
 
 // InterfaceThroughput tracks throughput for a single interface
@@ -47,6 +51,7 @@ type InterfaceThroughput struct {
 	PacketsSent      uint64
 	Mbps             float64
 	bytesSent        uint64
+	totalBytes       uint64 // Bytes sent on the interface, carried over when the tracker is re-initialized
 	lastUpdate       time.Time
 	throughput       float64
 	targetThroughput float64 // Current target for this interface (can be updated during ramping)
@@ -64,8 +69,12 @@ type NetworkLoadGenerator struct {
 	interfaceThroughputs map[string]*InterfaceThroughput
 	layer2Gen            *Layer2Generator // Layer 2 generator
 	usingLayer2          bool             // Whether we're using Layer 2 mode
+	paused               atomic.Bool      // Workers idle while set
 }
 
+// pausePollInterval is how often paused workers check whether to resume
+const pausePollInterval = 50 * time.Millisecond
+
 func NewNetworkLoadGenerator() *NetworkLoadGenerator {
 	return &NetworkLoadGenerator{
 		lastUpdate:           time.Now(),
@@ -113,6 +122,17 @@ func (g *NetworkLoadGenerator) SetInterfaceTargetThroughput(ifaceName string, mb
 	}
 }
 
+// SetPaused pauses or resumes sending on all interfaces. Workers and their
+// connections stay up, so sending continues immediately on resume.
+func (g *NetworkLoadGenerator) SetPaused(paused bool) {
+	g.paused.Store(paused)
+}
+
+// IsPaused returns whether sending is paused
+func (g *NetworkLoadGenerator) IsPaused() bool {
+	return g.paused.Load()
+}
+
 // GetTargetThroughput returns the current target throughput
 func (g *NetworkLoadGenerator) GetTargetThroughput() float64 {
 	g.mu.Lock()
@@ -176,6 +196,10 @@ func (g *NetworkLoadGenerator) getWorkerDelay(packetSize int) time.Duration {
 	return compensatedDelay
 }
 
+// staleThroughputAfter is how long without sent bytes until a measured rate is reported as 0.
+// Rates are only recomputed while sending, so they would otherwise stick after load stops.
+const staleThroughputAfter = 3 * time.Second
+
 func (g *NetworkLoadGenerator) GetThroughput() float64 {
 	g.mu.Lock()
 	defer g.mu.Unlock()
@@ -185,6 +209,9 @@ func (g *NetworkLoadGenerator) GetThroughput() float64 {
 		return g.GetLayer2Throughput()
 	}
 
+	if time.Since(g.lastUpdate) > staleThroughputAfter {
+		return 0
+	}
 	return g.throughput
 }
 
@@ -201,7 +228,11 @@ func (g *NetworkLoadGenerator) GetThroughputByInterface() map[string]float64 {
 	result := make(map[string]float64)
 	for name, it := range g.interfaceThroughputs {
 		it.mu.Lock()
-		result[name] = it.throughput
+		if time.Since(it.lastUpdate) > staleThroughputAfter {
+			result[name] = 0
+		} else {
+			result[name] = it.throughput
+		}
 		it.mu.Unlock()
 	}
 	return result
@@ -226,6 +257,27 @@ func (g *NetworkLoadGenerator) GetTargetThroughputByInterface() map[string]float
 	return result
 }
 
+// GetBytesSentByInterface returns the bytes sent per interface since its load
+// was first started. The counters never go back, also when a test plan step
+// restarts an interface, so sampling them gives the throughput over any
+// interval, independent of the one-second measurement window.
+func (g *NetworkLoadGenerator) GetBytesSentByInterface() map[string]uint64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.usingLayer2 {
+		return g.GetLayer2BytesSentByInterface()
+	}
+
+	result := make(map[string]uint64, len(g.interfaceThroughputs))
+	for name, it := range g.interfaceThroughputs {
+		it.mu.RLock()
+		result[name] = it.totalBytes
+		it.mu.RUnlock()
+	}
+	return result
+}
+
 // getOrCreateInterfaceThroughput gets or creates a throughput tracker for an interface
 func (g *NetworkLoadGenerator) getOrCreateInterfaceThroughput(ifaceName string) *InterfaceThroughput {
 	g.mu.Lock()
@@ -269,6 +321,13 @@ func (g *NetworkLoadGenerator) initInterfaceThroughput(ic InterfaceConfig) *Inte
 		targetThroughput: initialTarget,
 		workers:          ic.Workers,
 	}
+	// Keep the byte counter of a restarted interface, so samplers holding its
+	// previous value get the bytes sent since
+	if old, exists := g.interfaceThroughputs[ifaceName]; exists {
+		old.mu.RLock()
+		it.totalBytes = old.totalBytes
+		old.mu.RUnlock()
+	}
 	g.interfaceThroughputs[ifaceName] = it
 	
 	fmt.Printf("[initInterfaceThroughput] Initialized '%s': initialTarget=%.1f Mbps, workers=%d, rampSteps=%d\n",
@@ -309,6 +368,7 @@ func (g *NetworkLoadGenerator) updateInterfaceThroughput(ifaceName string, bytes
 	defer it.mu.Unlock()
 	
 	it.bytesSent += uint64(bytesSent)
+	it.totalBytes += uint64(bytesSent)
 	now := time.Now()
 	elapsed := now.Sub(it.lastUpdate).Seconds()
 	
@@ -530,6 +590,10 @@ func (g *NetworkLoadGenerator) runUDPWorkerWithConfig(ctx context.Context, id in
 		case <-ctx.Done():
 			return
 		default:
+			if g.paused.Load() {
+				PreciseSleep(pausePollInterval)
+				continue
+			}
 			delay := g.getWorkerDelayForInterface(config.PacketSize, ifaceName)
 			
 			// Send packet
@@ -603,6 +667,10 @@ func (g *NetworkLoadGenerator) runTCPWorkerWithConfig(ctx context.Context, id in
 		case <-ctx.Done():
 			return
 		default:
+			if g.paused.Load() {
+				PreciseSleep(pausePollInterval)
+				continue
+			}
 			delay := g.getWorkerDelayForInterface(config.PacketSize, ifaceName)
 			if delay > 0 {
 				PreciseSleep(delay)
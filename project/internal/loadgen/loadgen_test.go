@@ -0,0 +1,27 @@
+package loadgen
+
+import "testing"
+
+func TestBytesSentCarryOverRestart(t *testing.T) {
+	g := NewNetworkLoadGenerator()
+	ic := InterfaceConfig{Name: "eth1", Workers: 1}
+	g.initInterfaceThroughput(ic)
+	g.updateInterfaceThroughput("eth1", 1000)
+	prev := g.GetBytesSentByInterface()["eth1"]
+
+	// A test plan step restarts the interface, which then sends more than
+	// before the restart until the next sample
+	g.initInterfaceThroughput(ic)
+	g.updateInterfaceThroughput("eth1", 1500)
+	bytes := g.GetBytesSentByInterface()["eth1"]
+
+	if bytes < prev || bytes-prev != 1500 {
+		t.Errorf("counter %d after %d, want 1500 bytes since the previous sample", bytes, prev)
+	}
+
+	// A new interface starts at zero
+	g.initInterfaceThroughput(InterfaceConfig{Name: "eth2", Workers: 1})
+	if n := g.GetBytesSentByInterface()["eth2"]; n != 0 {
+		t.Errorf("new interface counter = %d, want 0", n)
+	}
+}
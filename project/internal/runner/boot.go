@@ -0,0 +1,211 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"project/internal/fritzbox"
+)
+
+// BootConfig configures a power-cycle test: the outlet is switched off and on
+// again and power is sampled until the DUT answers on the network
+type BootConfig struct {
+	OffTime       time.Duration // How long the outlet stays off
+	TargetIP      string        // Boot is complete once TargetIP:TargetPort accepts TCP connections
+	TargetPort    int
+	Timeout       time.Duration // Maximum wait for the target after switching on
+	PostReadyTime time.Duration // Keep sampling after the target became reachable
+}
+
+// BootResult contains the boot cost of a power-cycle test
+type BootResult struct {
+	SwitchOnTime    time.Time
+	Ready           bool // Target became reachable within the timeout
+	ReadyTime       time.Time
+	BootSeconds     float64 // Switch-on until the target was reachable
+	PeakPowerMW     float64 // Inrush peak between switch-on and ready
+	EnergyToReadyWh float64 // Energy between switch-on and ready (or the timeout)
+	OffPowerMW      float64 // Average power while the outlet was off
+}
+
+const (
+	PhaseOff  Phase = "off"
+	PhaseBoot Phase = "boot"
+)
+
+const (
+	// defaultBootTimeout is used when BootConfig.Timeout is not set
+	defaultBootTimeout = 5 * time.Minute
+	// defaultBootReadTimeout limits meter reads when sampling back-to-back
+	defaultBootReadTimeout = 5 * time.Second
+	// bootProbeInterval is the delay between reachability checks while booting
+	bootProbeInterval = 500 * time.Millisecond
+)
+
+// CanSwitch reports whether the DUT meter can switch its outlet (required for boot tests)
+func (r *Runner) CanSwitch() bool {
+	_, ok := r.meter.(fritzbox.Switcher)
+	return ok
+}
+
+// runBootTest power-cycles the DUT through the meter's outlet. Power is sampled
+// every config.Interval, or as fast as the meter answers if it is zero.
+func (r *Runner) runBootTest(ctx context.Context, config TestConfig, updateChan chan<- DataPoint) (*TestResult, error) {
+	sw, ok := r.meter.(fritzbox.Switcher)
+	if !ok {
+		return nil, fmt.Errorf("power meter cannot switch its outlet")
+	}
+	boot := *config.Boot
+	if boot.TargetIP == "" {
+		return nil, fmt.Errorf("boot test needs a target IP")
+	}
+	if boot.Timeout <= 0 {
+		boot.Timeout = defaultBootTimeout
+	}
+	if config.ReadTimeout <= 0 {
+		config.ReadTimeout = defaultBootReadTimeout
+	}
+
+	result := &TestResult{
+		Config:     config,
+		DataPoints: make([]DataPoint, 0),
+		StartTime:  time.Now(),
+		Boot:       &BootResult{},
+	}
+	r.beginRecording(result)
+
+	takeEvents, endTest := r.beginTest()
+	defer endTest()
+
+	if err := r.runHooks(ctx, result, HookBeforeTest, "", ""); err != nil {
+		result.EndTime = time.Now()
+		return result, err
+	}
+
+	s := &sampler{r: r, ctx: ctx, config: config, result: result, updateChan: updateChan, takeEvents: takeEvents}
+
+	fmt.Printf("Starting boot test: %s (target %s:%d)\n", config.Description, boot.TargetIP, boot.TargetPort)
+
+	// Never leave the DUT without power, even if the test is cancelled
+	switchedOff := false
+	defer func() {
+		if switchedOff {
+			if err := sw.SetSwitch(true); err != nil {
+				fmt.Printf("Failed to switch outlet back on: %v\n", err)
+			}
+		}
+	}()
+
+	// Phase 1: outlet off
+	if err := sw.SetSwitch(false); err != nil {
+		result.EndTime = time.Now()
+		return result, err
+	}
+	switchedOff = true
+	r.addEvent(EventSwitch, "Outlet switched off")
+
+	offDone, stopOffTimer := closeAfter(boot.OffTime)
+	defer stopOffTimer()
+	if err := s.sampleUntil(PhaseOff, offDone, nil); err != nil {
+		result.EndTime = time.Now()
+		return result, err
+	}
+
+	// Phase 2: switch on and wait for the target
+	if err := sw.SetSwitch(true); err != nil {
+		result.EndTime = time.Now()
+		return result, err
+	}
+	switchedOff = false
+	result.Boot.SwitchOnTime = time.Now()
+	r.addEvent(EventSwitch, "Outlet switched on")
+
+	probeCtx, cancelProbe := context.WithTimeout(ctx, boot.Timeout)
+	defer cancelProbe()
+	var readyTime time.Time // Written before bootDone is closed
+	bootDone := make(chan struct{})
+	go func() {
+		defer close(bootDone)
+		for {
+			if r.TestTargetConnection(boot.TargetIP, boot.TargetPort) == nil {
+				readyTime = time.Now()
+				return
+			}
+			select {
+			case <-probeCtx.Done():
+				return
+			case <-time.After(bootProbeInterval):
+			}
+		}
+	}()
+	if err := s.sampleUntil(PhaseBoot, bootDone, nil); err != nil {
+		result.EndTime = time.Now()
+		return result, err
+	}
+
+	bootEnd := time.Now()
+	readyEvent := Event{Type: EventTargetReady, Timestamp: bootEnd}
+	if !readyTime.IsZero() {
+		bootEnd = readyTime
+		result.Boot.Ready = true
+		result.Boot.ReadyTime = readyTime
+		result.Boot.BootSeconds = bootEnd.Sub(result.Boot.SwitchOnTime).Seconds()
+		readyEvent.Timestamp = readyTime
+		readyEvent.Message = fmt.Sprintf("Target reachable after %.1fs", result.Boot.BootSeconds)
+	} else {
+		readyEvent.Message = fmt.Sprintf("Target not reachable within %s", boot.Timeout)
+	}
+	fmt.Printf("%s (%s:%d)\n", readyEvent.Message, boot.TargetIP, boot.TargetPort)
+
+	// The marker goes with a final boot sample, so it is streamed and recorded with it
+	if err := s.sample(PhaseBoot, readyEvent); err != nil {
+		result.EndTime = time.Now()
+		return result, err
+	}
+
+	// Phase 3: optional sampling after boot
+	if boot.PostReadyTime > 0 {
+		postDone, stopPostTimer := closeAfter(boot.PostReadyTime)
+		defer stopPostTimer()
+		if err := s.sampleUntil(PhasePostTest, postDone, nil); err != nil {
+			result.EndTime = time.Now()
+			return result, err
+		}
+	}
+
+	r.calculateBootResult(result, bootEnd)
+	result.EndTime = time.Now()
+	fmt.Printf("Boot test completed. Peak %.0f mW, %.3f Wh to ready\n", result.Boot.PeakPowerMW, result.Boot.EnergyToReadyWh)
+	return result, nil
+}
+
+// calculateBootResult derives off power, inrush peak and energy-to-ready from the samples
+func (r *Runner) calculateBootResult(result *TestResult, bootEnd time.Time) {
+	var offSum float64
+	var offCount int
+	var bootPoints []DataPoint
+	for _, dp := range result.DataPoints {
+		if !dp.FreshPower() {
+			continue
+		}
+		switch dp.Phase {
+		case PhaseOff:
+			offSum += dp.PowerMW
+			offCount++
+		case PhaseBoot:
+			if dp.Timestamp.After(bootEnd) {
+				continue
+			}
+			bootPoints = append(bootPoints, dp)
+			if dp.PowerMW > result.Boot.PeakPowerMW {
+				result.Boot.PeakPowerMW = dp.PowerMW
+			}
+		}
+	}
+
+	if offCount > 0 {
+		result.Boot.OffPowerMW = offSum / float64(offCount)
+	}
+	result.Boot.EnergyToReadyWh = IntegrateEnergyWh(bootPoints, result.Boot.SwitchOnTime, bootEnd)
+}
@@ -0,0 +1,93 @@
+package runner
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"project/internal/loadgen"
+)
+
+// switchMeter is a switchable outlet with constant power
+type switchMeter struct{}
+
+func (switchMeter) GetCurrentPower() (float64, error) { return 1850, nil }
+func (switchMeter) TestConnection() error             { return nil }
+func (switchMeter) SetSwitch(on bool) error           { return nil }
+
+// pointRecorder keeps the recorded data points of one test
+type pointRecorder struct {
+	points []DataPoint
+}
+
+func (p *pointRecorder) BeginRecording(result *TestResult) (Recording, error) { return p, nil }
+func (p *pointRecorder) AddDataPoint(dp DataPoint) error {
+	p.points = append(p.points, dp)
+	return nil
+}
+func (p *pointRecorder) AddThroughputSamples(samples []ThroughputSample) error { return nil }
+func (p *pointRecorder) Finish(result *TestResult, err error) error            { return nil }
+
+// readyEvents returns the ready events of points
+func readyEvents(points []DataPoint) []Event {
+	var events []Event
+	for _, dp := range points {
+		for _, evt := range dp.Events {
+			if evt.Type == EventTargetReady {
+				events = append(events, evt)
+			}
+		}
+	}
+	return events
+}
+
+func TestBootReadyEventStreamedAndRecorded(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer target.Close()
+	go func() {
+		for {
+			conn, err := target.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	r := NewRunner(switchMeter{}, loadgen.NewNetworkLoadGenerator())
+	rec := &pointRecorder{}
+	r.SetRecorder(rec)
+
+	updates := make(chan DataPoint, 1000)
+	config := TestConfig{
+		Interval: 10 * time.Millisecond,
+		Boot: &BootConfig{
+			OffTime:    50 * time.Millisecond,
+			TargetIP:   "127.0.0.1",
+			TargetPort: target.Addr().(*net.TCPAddr).Port,
+			Timeout:    5 * time.Second,
+		},
+	}
+	result, err := r.RunTest(context.Background(), config, updates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(updates)
+	var streamed []DataPoint
+	for dp := range updates {
+		streamed = append(streamed, dp)
+	}
+
+	if !result.Boot.Ready {
+		t.Fatal("target not ready")
+	}
+	for name, points := range map[string][]DataPoint{"result": result.DataPoints, "streamed": streamed, "recorded": rec.points} {
+		if events := readyEvents(points); len(events) != 1 || !events[0].Timestamp.Equal(result.Boot.ReadyTime) {
+			t.Errorf("%s: ready events %+v, want one at %s", name, events, result.Boot.ReadyTime)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrNotControllable is returned by the control API when no load test or test
+// plan is running. Boot tests and idle characterizations cannot be controlled.
+var ErrNotControllable = errors.New("no controllable test running")
+
+// testControl holds the runtime controls of a running load test or test plan
+type testControl struct {
+	extend chan time.Duration // Extensions of the current phase, applied by collectData
+}
+
+// ControlState is the current state of the runtime controls
+type ControlState struct {
+	Active                      bool               `json:"active"`
+	LoadPaused                  bool               `json:"load_paused"`
+	Interfaces                  []string           `json:"interfaces,omitempty"`
+	TargetThroughputByInterface map[string]float64 `json:"target_throughput_by_interface,omitempty"`
+}
+
+// beginControl enables the control API for the running test and returns the
+// channel delivering phase extensions
+func (r *Runner) beginControl() <-chan time.Duration {
+	control := &testControl{extend: make(chan time.Duration, 10)}
+	r.eventMu.Lock()
+	r.control = control
+	r.eventMu.Unlock()
+	r.loadGen.SetPaused(false)
+	return control.extend
+}
+
+// endControl disables the control API and resumes a paused load generator
+func (r *Runner) endControl() {
+	r.eventMu.Lock()
+	r.control = nil
+	r.eventMu.Unlock()
+	r.loadGen.SetPaused(false)
+}
+
+// activeControl returns the controls of the running test
+func (r *Runner) activeControl() (*testControl, error) {
+	r.eventMu.Lock()
+	defer r.eventMu.Unlock()
+	if r.control == nil {
+		return nil, ErrNotControllable
+	}
+	return r.control, nil
+}
+
+// ControlState returns whether a test can be controlled and its current load settings
+func (r *Runner) ControlState() ControlState {
+	if _, err := r.activeControl(); err != nil {
+		return ControlState{}
+	}
+	targets := r.loadGen.GetTargetThroughputByInterface()
+	interfaces := make([]string, 0, len(targets))
+	for name := range targets {
+		interfaces = append(interfaces, name)
+	}
+	sort.Strings(interfaces)
+	return ControlState{
+		Active:                      true,
+		LoadPaused:                  r.loadGen.IsPaused(),
+		Interfaces:                  interfaces,
+		TargetThroughputByInterface: targets,
+	}
+}
+
+// SetInterfaceThroughput changes the target throughput of one interface of the
+// running test (0 = unlimited). A running ramp overrides it at its next step.
+func (r *Runner) SetInterfaceThroughput(ifaceName string, mbps float64) error {
+	if _, err := r.activeControl(); err != nil {
+		return err
+	}
+	if mbps < 0 {
+		return fmt.Errorf("negative throughput %.1f Mbps", mbps)
+	}
+
+	key := interfaceKey(ifaceName)
+	if _, ok := r.loadGen.GetTargetThroughputByInterface()[key]; !ok {
+		return fmt.Errorf("interface %q is not generating load", ifaceName)
+	}
+
+	r.loadGen.SetInterfaceTargetThroughput(ifaceName, mbps)
+	target := fmt.Sprintf("%.1f Mbps", mbps)
+	if mbps == 0 {
+		target = "unlimited"
+	}
+	r.addEvent(EventControl, fmt.Sprintf("[%s] Target throughput set to %s", key, target))
+	return nil
+}
+
+// interfaceKey is the load generator's name for an interface, which tracks
+// OS routing as "default"
+func interfaceKey(ifaceName string) string {
+	if ifaceName == "" {
+		return "default"
+	}
+	return ifaceName
+}
+
+// PauseLoad stops sending on all interfaces while power sampling continues.
+// The samples stay in the current phase.
+func (r *Runner) PauseLoad() error {
+	if _, err := r.activeControl(); err != nil {
+		return err
+	}
+	if r.loadGen.IsPaused() {
+		return fmt.Errorf("load is already paused")
+	}
+	r.loadGen.SetPaused(true)
+	r.addEvent(EventControl, "Load paused")
+	return nil
+}
+
+// ResumeLoad continues sending after PauseLoad
+func (r *Runner) ResumeLoad() error {
+	if _, err := r.activeControl(); err != nil {
+		return err
+	}
+	if !r.loadGen.IsPaused() {
+		return fmt.Errorf("load is not paused")
+	}
+	r.loadGen.SetPaused(false)
+	r.addEvent(EventControl, "Load resumed")
+	return nil
+}
+
+// ExtendPhase lengthens the current phase, e.g. the load phase, by d. A phase
+// already held open by steady-state gating runs for d more and is then
+// checked for stability again.
+func (r *Runner) ExtendPhase(d time.Duration) error {
+	control, err := r.activeControl()
+	if err != nil {
+		return err
+	}
+	if d <= 0 {
+		return fmt.Errorf("extension must be positive")
+	}
+	select {
+	case control.extend <- d:
+		return nil
+	default:
+		return fmt.Errorf("too many pending extensions")
+	}
+}
@@ -0,0 +1,254 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// HookPoint is the point of a test at which a hook runs
+type HookPoint string
+
+const (
+	HookBeforeTest  HookPoint = "before_test"
+	HookPhaseChange HookPoint = "phase_change" // Before every phase or plan step of a load test or test plan
+	HookAfterTest   HookPoint = "after_test"   // Also after a stopped or failed test
+)
+
+// Hook failure policies
+const (
+	HookContinue = "continue" // Record the failure and go on (default)
+	HookAbort    = "abort"    // Fail the test
+)
+
+const (
+	// defaultHookTimeout is used when Hook.Timeout is not set
+	defaultHookTimeout = 30 * time.Second
+	// maxHookOutput is how much command output or response body is kept per hook
+	maxHookOutput = 16 << 10
+)
+
+// Hook runs a local command or an HTTP call at a defined point of a test, e.g.
+// to reconfigure the DUT or set static routes before a test
+type Hook struct {
+	Name      string            `json:"name,omitempty"`
+	At        HookPoint         `json:"at"`
+	Phase     string            `json:"phase,omitempty"`   // Phase change hooks only: run for this phase or step label only
+	Command   string            `json:"command,omitempty"` // Run with sh -c (cmd /C on Windows)
+	URL       string            `json:"url,omitempty"`     // HTTP call instead of a command
+	Method    string            `json:"method,omitempty"`  // Default POST
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      string            `json:"body,omitempty"` // Default: the hook context as JSON
+	Timeout   PlanDuration      `json:"timeout,omitempty"`
+	OnFailure string            `json:"on_failure,omitempty"` // continue or abort
+}
+
+// HookResult is the outcome of one hook run, stored with the test
+type HookResult struct {
+	Name            string    `json:"name"`
+	At              HookPoint `json:"at"`
+	Phase           string    `json:"phase,omitempty"`
+	StartTime       time.Time `json:"start_time"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Output          string    `json:"output,omitempty"` // Combined stdout and stderr, or the response body
+	Error           string    `json:"error,omitempty"`
+}
+
+// hookContext is passed to commands as environment variables and to HTTP
+// hooks as the default body
+type hookContext struct {
+	At         HookPoint `json:"at"`
+	TestName   string    `json:"test_name"`
+	DeviceName string    `json:"device_name"`
+	Phase      string    `json:"phase,omitempty"`
+	Error      string    `json:"error,omitempty"` // After-test hooks: why the test ended early
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// ParseHooks reads a JSON list of hooks and validates it
+func ParseHooks(data []byte) ([]Hook, error) {
+	var hooks []Hook
+	if err := json.Unmarshal(data, &hooks); err != nil {
+		return nil, fmt.Errorf("failed to parse hooks: %w", err)
+	}
+	if err := ValidateHooks(hooks); err != nil {
+		return nil, err
+	}
+	return hooks, nil
+}
+
+// ValidateHooks checks the hook points, targets and failure policies
+func ValidateHooks(hooks []Hook) error {
+	for i, hook := range hooks {
+		name := hook.displayName(i)
+		switch hook.At {
+		case HookBeforeTest, HookAfterTest:
+			if hook.Phase != "" {
+				return fmt.Errorf("hook %s: phase is only allowed for %s hooks", name, HookPhaseChange)
+			}
+		case HookPhaseChange:
+		default:
+			return fmt.Errorf("hook %s: unknown hook point %q", name, hook.At)
+		}
+		if (hook.Command == "") == (hook.URL == "") {
+			return fmt.Errorf("hook %s needs either a command or a URL", name)
+		}
+		if hook.OnFailure != "" && hook.OnFailure != HookContinue && hook.OnFailure != HookAbort {
+			return fmt.Errorf("hook %s: unknown failure policy %q", name, hook.OnFailure)
+		}
+		if hook.Timeout < 0 {
+			return fmt.Errorf("hook %s: negative timeout", name)
+		}
+	}
+	return nil
+}
+
+// HasCommands reports whether any hook runs a local command
+func HasCommands(hooks []Hook) bool {
+	for _, hook := range hooks {
+		if hook.Command != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// displayName is the hook's name or its 1-based position
+func (h Hook) displayName(index int) string {
+	if h.Name != "" {
+		return h.Name
+	}
+	return fmt.Sprintf("#%d", index+1)
+}
+
+// runHooks runs the test's hooks for a hook point in order and records their
+// results. It returns an error if a hook with the abort policy failed.
+func (r *Runner) runHooks(ctx context.Context, result *TestResult, at HookPoint, phase Phase, testErr string) error {
+	for i, hook := range result.Config.Hooks {
+		if hook.At != at || (hook.Phase != "" && hook.Phase != string(phase)) {
+			continue
+		}
+
+		hc := hookContext{
+			At:         at,
+			TestName:   result.Config.TestName,
+			DeviceName: result.Config.DeviceName,
+			Phase:      string(phase),
+			Error:      testErr,
+			Timestamp:  time.Now(),
+		}
+		hr := runHook(ctx, hook, hc)
+		hr.Name = hook.displayName(i)
+		result.Hooks = append(result.Hooks, hr)
+
+		if hr.Error == "" {
+			fmt.Printf("Hook %s (%s) finished in %.1fs\n", hr.Name, at, hr.DurationSeconds)
+			r.addEvent(EventHook, fmt.Sprintf("Hook %s finished", hr.Name))
+			continue
+		}
+		fmt.Printf("Hook %s (%s) failed: %s\n", hr.Name, at, hr.Error)
+		r.addEvent(EventHook, fmt.Sprintf("Hook %s failed: %s", hr.Name, hr.Error))
+		if hook.OnFailure == HookAbort {
+			return fmt.Errorf("hook %s failed: %s", hr.Name, hr.Error)
+		}
+	}
+	return nil
+}
+
+// runHook runs a single hook with its timeout
+func runHook(ctx context.Context, hook Hook, hc hookContext) HookResult {
+	hr := HookResult{At: hc.At, Phase: hc.Phase, StartTime: hc.Timestamp}
+	timeout := time.Duration(hook.Timeout)
+	if timeout <= 0 {
+		timeout = defaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var output []byte
+	var err error
+	if hook.Command != "" {
+		output, err = runHookCommand(ctx, hook, hc)
+	} else {
+		output, err = runHookRequest(ctx, hook, hc)
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+
+	hr.DurationSeconds = time.Since(hr.StartTime).Seconds()
+	hr.Output = string(output)
+	if len(output) > maxHookOutput {
+		hr.Output = string(output[:maxHookOutput]) + "\n[output truncated]"
+	}
+	if err != nil {
+		hr.Error = err.Error()
+	}
+	return hr
+}
+
+// runHookCommand runs the command with the platform shell. The hook context
+// is passed as HOOK_* environment variables.
+func runHookCommand(ctx context.Context, hook Hook, hc hookContext) ([]byte, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", hook.Command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", hook.Command)
+	}
+	cmd.Env = append(os.Environ(),
+		"HOOK_POINT="+string(hc.At),
+		"HOOK_TEST_NAME="+hc.TestName,
+		"HOOK_DEVICE_NAME="+hc.DeviceName,
+		"HOOK_PHASE="+hc.Phase,
+		"HOOK_ERROR="+hc.Error,
+	)
+	// Don't wait for background processes holding the output open after a timeout
+	cmd.WaitDelay = 2 * time.Second
+	return cmd.CombinedOutput()
+}
+
+// runHookRequest sends the HTTP call and fails on status codes of 400 and above
+func runHookRequest(ctx context.Context, hook Hook, hc hookContext) ([]byte, error) {
+	method := strings.ToUpper(hook.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+	body := []byte(hook.Body)
+	if hook.Body == "" && method != http.MethodGet {
+		body, _ = json.Marshal(hc)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range hook.Headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	output, err := io.ReadAll(io.LimitReader(resp.Body, maxHookOutput+1))
+	if err != nil {
+		return output, err
+	}
+	if resp.StatusCode >= 400 {
+		return output, fmt.Errorf("HTTP %s", resp.Status)
+	}
+	return output, nil
+}
@@ -0,0 +1,210 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// IdleConfig configures an idle characterization: passive sampling without
+// load until power has settled, then a steady-state measurement of
+// TestConfig.Duration
+type IdleConfig struct {
+	SettleTime    time.Duration // Minimum settle period before stability is checked
+	MaxSettleTime time.Duration // Start measuring anyway after this (0 = defaultMaxSettleTime)
+	Stability     StabilityCriterion
+}
+
+// IdleResult contains the steady-state statistics of an idle characterization
+type IdleResult struct {
+	Stabilized    bool // Stability criterion held before MaxSettleTime
+	SettleSeconds float64
+	SettleReason  string
+	MeanPowerMW   float64
+	NoiseFloorMW  float64  // Standard deviation of the steady-state samples
+	CI95LowMW     *float64 // 95% confidence interval of the mean (batch means),
+	CI95HighMW    *float64 // nil with too few samples
+	SampleCount   int
+}
+
+const (
+	PhaseSettle Phase = "settle"
+	PhaseIdle   Phase = "idle"
+)
+
+const (
+	// idleBatches is the number of batches for the batch-means confidence interval
+	idleBatches = 10
+	// idleBatchT is the two-sided 95% Student-t quantile for idleBatches-1 degrees of freedom
+	idleBatchT = 2.262
+	// defaultMaxSettleTime is used when IdleConfig.MaxSettleTime is not set
+	defaultMaxSettleTime = 30 * time.Minute
+	// minIdleWindowReadings is the number of readings the stability window must hold
+	minIdleWindowReadings = 10
+)
+
+// IdleStabilityWindow returns the default stability window at a poll interval:
+// the one of DefaultStabilityCriterion, widened to hold minIdleWindowReadings
+func IdleStabilityWindow(interval time.Duration) time.Duration {
+	window := DefaultStabilityCriterion().Window
+	if w := minIdleWindowReadings * interval; w > window {
+		window = w
+	}
+	return window
+}
+
+// MinIdleDuration returns the shortest steady-state measurement at a poll
+// interval with two readings per batch of the confidence interval
+func MinIdleDuration(interval time.Duration) time.Duration {
+	return 2 * idleBatches * interval
+}
+
+// Validate checks that the stability window and the measurement duration hold
+// enough readings at the poll interval for the stability check and the
+// confidence interval
+func (c IdleConfig) Validate(interval, duration time.Duration) error {
+	if interval <= 0 {
+		return nil
+	}
+	if min := minIdleWindowReadings * interval; c.Stability.Window < min {
+		return fmt.Errorf("stability window %s holds fewer than %d readings at a %s poll interval, use at least %s",
+			c.Stability.Window, minIdleWindowReadings, interval, min)
+	}
+	if min := MinIdleDuration(interval); duration < min {
+		return fmt.Errorf("idle measurement of %s holds fewer than %d readings at a %s poll interval, measure for at least %s",
+			duration, 2*idleBatches, interval, min)
+	}
+	return nil
+}
+
+// runIdleTest samples the DUT without load: first until power is stable, then
+// for config.Duration to characterize the steady state
+func (r *Runner) runIdleTest(ctx context.Context, config TestConfig, updateChan chan<- DataPoint) (*TestResult, error) {
+	idle := *config.Idle
+	if idle.Stability.Window <= 0 {
+		idle.Stability = DefaultStabilityCriterion()
+		idle.Stability.Window = IdleStabilityWindow(config.Interval)
+	}
+	if err := idle.Validate(config.Interval, config.Duration); err != nil {
+		return nil, err
+	}
+	if idle.MaxSettleTime <= 0 {
+		idle.MaxSettleTime = defaultMaxSettleTime
+	}
+
+	result := &TestResult{
+		Config:     config,
+		DataPoints: make([]DataPoint, 0),
+		StartTime:  time.Now(),
+		Idle:       &IdleResult{},
+	}
+	r.beginRecording(result)
+
+	takeEvents, endTest := r.beginTest()
+	defer endTest()
+
+	if err := r.runHooks(ctx, result, HookBeforeTest, "", ""); err != nil {
+		result.EndTime = time.Now()
+		return result, err
+	}
+
+	s := &sampler{r: r, ctx: ctx, config: config, result: result, updateChan: updateChan, takeEvents: takeEvents}
+
+	fmt.Printf("Starting idle characterization: %s\n", config.Description)
+
+	// Phase 1: settle until the stability criterion holds
+	r.addEvent(EventPhaseChange, "Settling")
+	settleStart := time.Now()
+	settleFrom := len(result.DataPoints)
+	settled := func() bool {
+		elapsed := time.Since(settleStart)
+		if elapsed < idle.SettleTime {
+			return false
+		}
+		stable, _, reason := idle.Stability.Evaluate(result.DataPoints[settleFrom:], time.Now())
+		if stable {
+			result.Idle.Stabilized = true
+			result.Idle.SettleReason = reason
+			return true
+		}
+		if elapsed >= idle.MaxSettleTime {
+			result.Idle.SettleReason = fmt.Sprintf("not stable after %s: %s", idle.MaxSettleTime, reason)
+			return true
+		}
+		return false
+	}
+	if err := s.sampleUntil(PhaseSettle, nil, settled); err != nil {
+		result.EndTime = time.Now()
+		return result, err
+	}
+	result.Idle.SettleSeconds = time.Since(settleStart).Seconds()
+	fmt.Printf("Settled after %.0fs: %s\n", result.Idle.SettleSeconds, result.Idle.SettleReason)
+
+	// Phase 2: steady-state measurement
+	r.addEvent(EventPhaseChange, "Idle Measurement ("+result.Idle.SettleReason+")")
+	measureDone, stopTimer := closeAfter(config.Duration)
+	defer stopTimer()
+	if err := s.sampleUntil(PhaseIdle, measureDone, nil); err != nil {
+		result.EndTime = time.Now()
+		return result, err
+	}
+
+	calculateIdleResult(result)
+	result.EndTime = time.Now()
+	fmt.Printf("Idle characterization completed. Mean %.0f mW, noise floor %.0f mW\n",
+		result.Idle.MeanPowerMW, result.Idle.NoiseFloorMW)
+	return result, nil
+}
+
+// calculateIdleResult derives mean, noise floor and confidence interval from the
+// valid samples of the idle phase. Repeated readings are kept so a steady DUT on
+// a slow meter is not reduced to a handful of samples. Consecutive samples are correlated, so the
+// interval is computed from the means of idleBatches consecutive batches.
+func calculateIdleResult(result *TestResult) {
+	var values []float64
+	for _, dp := range result.DataPoints {
+		if dp.Phase == PhaseIdle && dp.Valid() {
+			values = append(values, dp.PowerMW)
+		}
+	}
+	n := len(values)
+	result.Idle.SampleCount = n
+	if n == 0 {
+		return
+	}
+
+	mean, stdDev := meanStdDev(values)
+	result.Idle.MeanPowerMW = mean
+	result.Idle.NoiseFloorMW = stdDev
+
+	if n < 2*idleBatches {
+		return
+	}
+	batchSize := n / idleBatches
+	batchMeans := make([]float64, idleBatches)
+	for b := range batchMeans {
+		batchMeans[b], _ = meanStdDev(values[b*batchSize : (b+1)*batchSize])
+	}
+	_, batchStdDev := meanStdDev(batchMeans)
+	// Standard error from the sample standard deviation of the batch means
+	halfWidth := idleBatchT * batchStdDev / math.Sqrt(idleBatches-1)
+	low, high := mean-halfWidth, mean+halfWidth
+	result.Idle.CI95LowMW = &low
+	result.Idle.CI95HighMW = &high
+}
+
+// meanStdDev returns the mean and population standard deviation of values
+func meanStdDev(values []float64) (float64, float64) {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+
+	var variance float64
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(values)))
+}
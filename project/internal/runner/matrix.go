@@ -0,0 +1,134 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"project/internal/loadgen"
+)
+
+// MatrixConfig is a parameter grid for a test campaign. Every combination of
+// the listed values becomes one run; an empty list keeps the base config's value.
+type MatrixConfig struct {
+	Protocols   []string
+	PacketSizes []int
+	PortCounts  []int         // Number of interfaces, taken in order from the base config
+	Cooldown    time.Duration // Minimum post-test baseline between runs
+}
+
+// MatrixParams identifies one run of a campaign
+type MatrixParams struct {
+	Protocol   string `json:"protocol"`
+	PacketSize int    `json:"packet_size"`
+	Ports      int    `json:"ports"`
+}
+
+func (p MatrixParams) String() string {
+	return fmt.Sprintf("%s %dB %d port(s)", p.Protocol, p.PacketSize, p.Ports)
+}
+
+// MatrixRun is one expanded test of a campaign or a repetition group
+type MatrixRun struct {
+	Index  int
+	Params MatrixParams
+	Config TestConfig
+}
+
+// ExpandMatrix builds the runs of a campaign from a base load test config
+func ExpandMatrix(base TestConfig, m MatrixConfig, campaignID string) ([]MatrixRun, error) {
+	if !base.LoadEnabled {
+		return nil, fmt.Errorf("test matrix needs load generation")
+	}
+	ifaces := base.LoadConfig.InterfaceConfigs
+
+	protocols := m.Protocols
+	if len(protocols) == 0 {
+		protocols = []string{base.LoadConfig.Protocol}
+	}
+	packetSizes := m.PacketSizes
+	if len(packetSizes) == 0 {
+		packetSizes = []int{base.LoadConfig.PacketSize}
+	}
+	portCounts := m.PortCounts
+	if len(portCounts) == 0 {
+		portCounts = []int{len(ifaces)}
+	}
+
+	for _, protocol := range protocols {
+		switch protocol {
+		case "udp", "tcp":
+		case "layer2":
+			if base.LoadConfig.TargetMAC == "" {
+				return nil, fmt.Errorf("layer2 runs need a target MAC")
+			}
+		default:
+			return nil, fmt.Errorf("unknown protocol %q", protocol)
+		}
+	}
+	for _, size := range packetSizes {
+		if size <= 0 {
+			return nil, fmt.Errorf("invalid packet size %d", size)
+		}
+	}
+	for _, ports := range portCounts {
+		if ports <= 0 || ports > len(ifaces) {
+			return nil, fmt.Errorf("port count %d not possible with %d configured interface(s)", ports, len(ifaces))
+		}
+	}
+
+	var runs []MatrixRun
+	for _, ports := range portCounts {
+		for _, protocol := range protocols {
+			for _, size := range packetSizes {
+				params := MatrixParams{Protocol: protocol, PacketSize: size, Ports: ports}
+
+				config := base
+				config.LoadConfig.Protocol = protocol
+				config.LoadConfig.PacketSize = size
+				config.LoadConfig.InterfaceConfigs = append([]loadgen.InterfaceConfig(nil), ifaces[:ports]...)
+				config.TestName = fmt.Sprintf("%s [%s]", base.TestName, params)
+				config.Description = fmt.Sprintf("%s [%s]", base.Description, params)
+				config.CampaignID = campaignID
+				config.MatrixParams = &params
+
+				runs = append(runs, MatrixRun{Index: len(runs), Params: params, Config: config})
+			}
+		}
+	}
+
+	// Cool down after every run except the last
+	for i := 0; i < len(runs)-1; i++ {
+		if runs[i].Config.PostTestTime < m.Cooldown {
+			runs[i].Config.PostTestTime = m.Cooldown
+		}
+	}
+	return runs, nil
+}
+
+// RunSeries runs matrix runs or repetitions back-to-back, pausing for gap
+// between runs. onResult is called after every completed run; the series
+// stops at the first failed run or safety abort.
+func (r *Runner) RunSeries(ctx context.Context, runs []MatrixRun, gap time.Duration, updateChan chan<- DataPoint, onResult func(MatrixRun, *TestResult)) error {
+	for i, run := range runs {
+		if i > 0 && gap > 0 {
+			fmt.Printf("Waiting %s before the next run\n", gap)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(gap):
+			}
+		}
+
+		fmt.Printf("Run %d/%d: %s\n", run.Index+1, len(runs), run.Config.TestName)
+		result, err := r.RunTest(ctx, run.Config, updateChan)
+		if err != nil {
+			return fmt.Errorf("run %d (%s) failed: %w", run.Index+1, run.Config.TestName, err)
+		}
+		onResult(run, result)
+		if result.AbortReason != "" {
+			return fmt.Errorf("run %d (%s) aborted: %s", run.Index+1, run.Config.TestName, result.AbortReason)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package runner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"project/internal/loadgen"
+)
+
+// TestPlan is a declarative sequence of test steps. It replaces the pre-test,
+// load and post-test phases; every step is recorded as its own phase.
+type TestPlan struct {
+	Name  string     `json:"name,omitempty"`
+	Steps []PlanStep `json:"steps"`
+}
+
+// PlanStep runs the listed interfaces for Duration. A step without interfaces
+// is a baseline without load.
+type PlanStep struct {
+	Label      string          `json:"label"` // Phase label, unique within the plan
+	Duration   PlanDuration    `json:"duration"`
+	Interfaces []PlanInterface `json:"interfaces,omitempty"`
+}
+
+// PlanInterface is the load on one interface during a step. Unset protocol,
+// packet size and workers are taken from TestConfig.LoadConfig.
+type PlanInterface struct {
+	Name           string  `json:"name"`            // Interface name (empty = OS routing)
+	ThroughputMbps float64 `json:"throughput_mbps"` // Target throughput (0 = unlimited)
+	Protocol       string  `json:"protocol,omitempty"`
+	PacketSize     int     `json:"packet_size,omitempty"`
+	Workers        int     `json:"workers,omitempty"`
+}
+
+// PlanDuration is a time.Duration written as a duration string ("90s", "5m")
+// in plan files. Plain numbers are read as seconds.
+type PlanDuration time.Duration
+
+func (d PlanDuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *PlanDuration) UnmarshalJSON(data []byte) error {
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err == nil {
+		*d = PlanDuration(seconds * float64(time.Second))
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = PlanDuration(parsed)
+	return nil
+}
+
+// ParsePlan reads a JSON test plan and validates it
+func ParsePlan(data []byte) (*TestPlan, error) {
+	var plan TestPlan
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse test plan: %w", err)
+	}
+	if err := plan.Validate(); err != nil {
+		return nil, err
+	}
+	return &plan, nil
+}
+
+// Validate checks that the plan has steps with unique labels and positive durations
+func (p *TestPlan) Validate() error {
+	if len(p.Steps) == 0 {
+		return fmt.Errorf("test plan has no steps")
+	}
+	labels := make(map[string]bool)
+	for i, step := range p.Steps {
+		label := strings.TrimSpace(step.Label)
+		if label == "" {
+			return fmt.Errorf("step %d has no label", i+1)
+		}
+		if labels[label] {
+			return fmt.Errorf("step label %q is used more than once", label)
+		}
+		labels[label] = true
+		p.Steps[i].Label = label
+		if step.Duration <= 0 {
+			return fmt.Errorf("step %q needs a positive duration", label)
+		}
+		for _, pi := range step.Interfaces {
+			if pi.ThroughputMbps < 0 {
+				return fmt.Errorf("step %q: negative throughput for interface %q", label, pi.Name)
+			}
+			switch pi.Protocol {
+			case "", "udp", "tcp", "layer2":
+			default:
+				return fmt.Errorf("step %q: unknown protocol %q", label, pi.Protocol)
+			}
+		}
+	}
+	return nil
+}
+
+// TotalDuration is the sum of all step durations
+func (p *TestPlan) TotalDuration() time.Duration {
+	var total time.Duration
+	for _, step := range p.Steps {
+		total += time.Duration(step.Duration)
+	}
+	return total
+}
+
+// HasLoad reports whether any step generates load
+func (p *TestPlan) HasLoad() bool {
+	for _, step := range p.Steps {
+		if len(step.Interfaces) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// startStepLoad starts load generation for the interfaces of a step. The
+// returned function stops the load and waits until all generators returned.
+func (r *Runner) startStepLoad(ctx context.Context, base loadgen.Config, step PlanStep) func() {
+	stepCtx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+
+	for _, pi := range step.Interfaces {
+		ic := loadgen.InterfaceConfig{
+			Name:             pi.Name,
+			Workers:          pi.Workers,
+			TargetThroughput: pi.ThroughputMbps,
+		}
+		if ic.Workers == 0 {
+			ic.Workers = 10
+			for _, configured := range base.InterfaceConfigs {
+				if configured.Name == pi.Name && configured.Workers > 0 {
+					ic.Workers = configured.Workers
+				}
+			}
+		}
+
+		ifaceConfig := base
+		ifaceConfig.InterfaceConfigs = []loadgen.InterfaceConfig{ic}
+		if pi.Protocol != "" {
+			ifaceConfig.Protocol = pi.Protocol
+		}
+		if pi.PacketSize > 0 {
+			ifaceConfig.PacketSize = pi.PacketSize
+		}
+
+		ifaceName := pi.Name
+		if ifaceName == "" {
+			ifaceName = "OS-routing"
+		}
+		throughputStr := "unlimited"
+		if pi.ThroughputMbps > 0 {
+			throughputStr = fmt.Sprintf("%.1f Mbps", pi.ThroughputMbps)
+		}
+		r.addEvent(EventInterfaceStart, fmt.Sprintf("[%s] %s: %s %s", step.Label, ifaceName, ifaceConfig.Protocol, throughputStr))
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := r.loadGen.Start(stepCtx, ifaceConfig); err != nil {
+				fmt.Printf("Load generation error [%s]: %v\n", ifaceName, err)
+			}
+		}()
+	}
+
+	return func() {
+		cancel()
+		wg.Wait()
+	}
+}
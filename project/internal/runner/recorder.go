@@ -0,0 +1,71 @@
+package runner
+
+import "fmt"
+
+// Recorder persists tests while they run, so the data points of a stopped or
+// failed test and of a test interrupted by a crash are not lost
+type Recorder interface {
+	// BeginRecording is called once a test has started
+	BeginRecording(result *TestResult) (Recording, error)
+}
+
+// Recording receives the data points of one running test in order
+type Recording interface {
+	AddDataPoint(dp DataPoint) error
+	// AddThroughputSamples receives the throughput samples taken since the previous call
+	AddThroughputSamples(samples []ThroughputSample) error
+	// Finish stores the final result; err is the error returned by RunTest
+	Finish(result *TestResult, err error) error
+}
+
+// SetRecorder persists all following tests with rec. It must not be called
+// while a test is running.
+func (r *Runner) SetRecorder(rec Recorder) {
+	r.recorder = rec
+}
+
+// beginRecording starts recording the test of result. A test whose recording
+// cannot be started still runs, it is just not persisted.
+func (r *Runner) beginRecording(result *TestResult) {
+	r.recording = nil
+	if r.recorder == nil {
+		return
+	}
+	recording, err := r.recorder.BeginRecording(result)
+	if err != nil {
+		fmt.Printf("Failed to start recording the test: %v\n", err)
+		return
+	}
+	r.recording = recording
+}
+
+// recordDataPoint adds a data point to the recording of the running test
+func (r *Runner) recordDataPoint(dp DataPoint) {
+	if r.recording == nil {
+		return
+	}
+	if err := r.recording.AddDataPoint(dp); err != nil {
+		fmt.Printf("Failed to record data point: %v\n", err)
+	}
+}
+
+// recordThroughputSamples adds throughput samples to the recording of the running test
+func (r *Runner) recordThroughputSamples(samples []ThroughputSample) {
+	if r.recording == nil {
+		return
+	}
+	if err := r.recording.AddThroughputSamples(samples); err != nil {
+		fmt.Printf("Failed to record throughput samples: %v\n", err)
+	}
+}
+
+// endRecording stores the final result of the recorded test
+func (r *Runner) endRecording(result *TestResult, testErr error) {
+	if r.recording == nil {
+		return
+	}
+	if err := r.recording.Finish(result, testErr); err != nil {
+		fmt.Printf("Failed to finish recording the test: %v\n", err)
+	}
+	r.recording = nil
+}
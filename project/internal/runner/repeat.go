@@ -0,0 +1,30 @@
+package runner
+
+import "fmt"
+
+// ExpandRepetitions repeats every run count times in a row. The repetitions of
+// a run share a group ID: groupID itself for a single run, groupID-<n> for the
+// n-th run of a matrix.
+func ExpandRepetitions(runs []MatrixRun, count int, groupID string) []MatrixRun {
+	if count <= 1 {
+		return runs
+	}
+
+	expanded := make([]MatrixRun, 0, len(runs)*count)
+	for i, run := range runs {
+		group := groupID
+		if len(runs) > 1 {
+			group = fmt.Sprintf("%s-%d", groupID, i+1)
+		}
+		for rep := 1; rep <= count; rep++ {
+			config := run.Config
+			config.TestName = fmt.Sprintf("%s (run %d/%d)", run.Config.TestName, rep, count)
+			config.GroupID = group
+			config.Repetition = rep
+			config.Repetitions = count
+
+			expanded = append(expanded, MatrixRun{Index: len(expanded), Params: run.Params, Config: config})
+		}
+	}
+	return expanded
+}
@@ -2,7 +2,9 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"sync"
 	"time"
@@ -20,9 +22,50 @@ type TestConfig struct {
 	TestName     string // User-defined test name
 	DeviceName   string // Device under test name
 
+	// Power meter reads
+	ReadTimeout  time.Duration // Per-attempt timeout (0 = poll interval)
+	ReadRetries  int           // Additional attempts after a failed read
+	RetryBackoff time.Duration // Delay before the first retry, doubled for each further retry
+
+	// Throughput sampling interval while load runs (0 = only with every power reading)
+	ThroughputInterval time.Duration
+
 	// Load Generation
 	LoadEnabled bool
 	LoadConfig  loadgen.Config // Complete load generation configuration
+
+	// Power-cycle boot test instead of the load test (requires a switchable outlet)
+	Boot *BootConfig
+	// Idle characterization instead of the load test (Duration is the steady-state measurement)
+	Idle *IdleConfig
+
+	// Optional steady-state gating: phases end after their duration only once power is stable
+	SteadyState *SteadyStateConfig
+
+	// Optional test plan replacing the pre-test, load and post-test phases
+	Plan *TestPlan
+
+	// Optional safety rules ending a load test or test plan early
+	Abort *AbortRules
+
+	// Commands and HTTP calls run before the test, at phase changes and after the test
+	Hooks []Hook
+
+	// Set for the runs of a test matrix campaign
+	CampaignID   string
+	MatrixParams *MatrixParams
+
+	// Set for repeated trials: the repetitions of a config share a group ID
+	GroupID     string
+	Repetition  int // 1-based
+	Repetitions int
+}
+
+// SteadyStateConfig extends each phase beyond its duration until the stability
+// criterion holds on the phase's samples, by at most MaxExtension
+type SteadyStateConfig struct {
+	Stability    StabilityCriterion
+	MaxExtension time.Duration
 }
 
 // Phase represents the current test phase
@@ -43,6 +86,11 @@ const (
 	EventInterfaceStart  EventType = "iface_start"
 	EventInterfaceStop   EventType = "iface_stop"
 	EventCustom          EventType = "custom"
+	EventSwitch          EventType = "switch" // Outlet switched on or off
+	EventTargetReady     EventType = "ready"  // Target reachable after power-on
+	EventControl         EventType = "control" // Load changed through the control API
+	EventAbort           EventType = "abort"   // Safety rule stopped the test
+	EventHook            EventType = "hook"    // Hook finished or failed
 )
 
 // Event represents a marker or event in the timeline
@@ -52,9 +100,34 @@ type Event struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// SampleQuality describes how a data point's power reading was obtained
+type SampleQuality string
+
+const (
+	QualityOK      SampleQuality = "ok"
+	QualityRetried SampleQuality = "retried" // Read succeeded after one or more retries
+	QualityStale   SampleQuality = "stale"   // Read failed, last good power value is held
+	QualityMissing SampleQuality = "missing" // Read failed and no recent value is available
+)
+
+const (
+	// defaultRetryBackoff is used when TestConfig.RetryBackoff is not set
+	defaultRetryBackoff = 500 * time.Millisecond
+	// staleHoldIntervals is how many poll intervals a good value is held after failed reads
+	staleHoldIntervals = 3
+)
+
 type DataPoint struct {
 	Timestamp                   time.Time          `json:"timestamp"`
 	PowerMW                     float64            `json:"power_mw"`
+	VoltageV                    *float64           `json:"voltage_v,omitempty"`
+	CurrentA                    *float64           `json:"current_a,omitempty"`
+	PowerFactor                 *float64           `json:"power_factor,omitempty"`
+	EnergyWh                    *float64           `json:"energy_wh,omitempty"`
+	MeterTimestamp              *time.Time         `json:"meter_timestamp,omitempty"`
+	PowerByMeter                map[string]float64 `json:"power_by_meter,omitempty"` // Additional meters, keyed by name
+	Quality                     SampleQuality      `json:"quality,omitempty"`
+	Repeated                    bool               `json:"repeated,omitempty"` // Meter had not refreshed the reading since the previous tick
 	ThroughputMbps              float64            `json:"throughput_mbps"`
 	ThroughputByInterface       map[string]float64 `json:"throughput_by_interface,omitempty"`
 	TargetThroughputByInterface map[string]float64 `json:"target_throughput_by_interface,omitempty"`
@@ -62,30 +135,140 @@ type DataPoint struct {
 	Events                      []Event            `json:"events,omitempty"`
 }
 
+// Valid reports whether the power reading was actually measured for this tick.
+// Data points saved before quality flags existed are treated as valid.
+func (dp DataPoint) Valid() bool {
+	return dp.Quality == "" || dp.Quality == QualityOK || dp.Quality == QualityRetried
+}
+
+// FreshPower reports whether the power reading is valid and was refreshed by the
+// meter since the previous tick, i.e. whether it counts towards power statistics.
+func (dp DataPoint) FreshPower() bool {
+	return dp.Valid() && !dp.Repeated
+}
+
+// PhaseBoundary records the meter's energy counter when a phase starts and ends
+type PhaseBoundary struct {
+	Phase         Phase     `json:"phase"`
+	StartTime     time.Time `json:"start_time"`
+	EndTime       time.Time `json:"end_time"`
+	StartEnergyWh *float64  `json:"start_energy_wh,omitempty"` // nil if the meter has no energy counter
+	EndEnergyWh   *float64  `json:"end_energy_wh,omitempty"`
+	EndReason     string    `json:"end_reason,omitempty"` // Why a steady-state gated phase ended
+}
+
 type TestResult struct {
-	Config     TestConfig
-	DataPoints []DataPoint
-	StartTime  time.Time
-	EndTime    time.Time
+	Config          TestConfig
+	DataPoints      []DataPoint
+	PhaseBoundaries []PhaseBoundary
+	Boot            *BootResult // Set for boot tests
+	Idle            *IdleResult // Set for idle characterizations
+	AbortReason     string      // Set if a safety rule stopped the test
+	Hooks           []HookResult
+	StartTime       time.Time
+	EndTime         time.Time
+
+	// Throughput every Config.ThroughputInterval during load, in addition to the data points
+	ThroughputSamples []ThroughputSample
 }
 
+// NamedMeter is an additional power meter polled alongside the DUT meter,
+// e.g. for the load-generating PC or a PoE-powered access point
+type NamedMeter struct {
+	Name  string
+	Meter fritzbox.PowerMeter
+	reads *meterReader
+}
+
+// PrimaryMeterName is reserved for the DUT meter
+const PrimaryMeterName = "dut"
+
 type Runner struct {
-	meter      fritzbox.PowerMeter
+	meter       fritzbox.PowerMeter
+	meterReads  *meterReader // Reads of meter, one at a time
+	extraMeters []NamedMeter
 	loadGen    loadgen.LoadGenerator
 	eventMu    sync.Mutex
 	eventChan  chan Event
 	testActive bool
+	control    *testControl // Runtime controls of the running load test, guarded by eventMu
+	recorder   Recorder
+	recording  Recording // Recording of the running test, only used by the test's goroutine
+
+	throughputUpdates chan<- ThroughputSample
 }
 
 func NewRunner(meter fritzbox.PowerMeter, lg loadgen.LoadGenerator) *Runner {
 	return &Runner{
-		meter:   meter,
-		loadGen: lg,
+		meter:      meter,
+		meterReads: &meterReader{meter: meter},
+		loadGen:    lg,
+	}
+}
+
+// AddMeter registers an additional meter that is read on every tick
+func (r *Runner) AddMeter(name string, meter fritzbox.PowerMeter) error {
+	if name == "" || name == PrimaryMeterName {
+		return fmt.Errorf("invalid meter name %q", name)
+	}
+	for _, m := range r.extraMeters {
+		if m.Name == name {
+			return fmt.Errorf("meter %q already exists", name)
+		}
+	}
+	r.extraMeters = append(r.extraMeters, NamedMeter{Name: name, Meter: meter, reads: &meterReader{meter: meter}})
+	return nil
+}
+
+// MeterNames returns the names of the additional meters
+func (r *Runner) MeterNames() []string {
+	names := make([]string, 0, len(r.extraMeters))
+	for _, m := range r.extraMeters {
+		names = append(names, m.Name)
+	}
+	return names
+}
+
+// Close releases the meters that hold resources, such as instrument
+// connections or the simulated DUT's model goroutine
+func (r *Runner) Close() error {
+	meters := []fritzbox.PowerMeter{r.meter}
+	for _, m := range r.extraMeters {
+		meters = append(meters, m.Meter)
+	}
+	var errs []error
+	for _, meter := range meters {
+		if c, ok := meter.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resetMeters restarts replayed recordings so every test plays back from the beginning
+func (r *Runner) resetMeters() {
+	if m, ok := r.meter.(fritzbox.Resetter); ok {
+		m.Reset()
+	}
+	for _, m := range r.extraMeters {
+		if m, ok := m.Meter.(fritzbox.Resetter); ok {
+			m.Reset()
+		}
 	}
 }
 
 func (r *Runner) TestFritzboxConnection() error {
-	return r.meter.TestConnection()
+	if err := r.meter.TestConnection(); err != nil {
+		return err
+	}
+	for _, m := range r.extraMeters {
+		if err := m.Meter.TestConnection(); err != nil {
+			return fmt.Errorf("meter %s: %w", m.Name, err)
+		}
+	}
+	return nil
 }
 
 func (r *Runner) TestTargetConnection(targetIP string, targetPort int) error {
@@ -146,30 +329,14 @@ func (r *Runner) IsTestActive() bool {
 	return r.testActive
 }
 
-// RunTest starts a test and streams data points to the updateChan
-func (r *Runner) RunTest(ctx context.Context, config TestConfig, updateChan chan<- DataPoint) (*TestResult, error) {
-	result := &TestResult{
-		Config:     config,
-		DataPoints: make([]DataPoint, 0),
-		StartTime:  time.Now(),
-	}
-
-	// Initialize event channel
+// beginTest marks a test as active and starts collecting events. takeEvents
+// returns the events queued since its last call, end stops the collection.
+func (r *Runner) beginTest() (takeEvents func() []Event, end func()) {
 	r.eventMu.Lock()
-	r.eventChan = make(chan Event, 100)
+	eventChan := make(chan Event, 100)
+	r.eventChan = eventChan
 	r.testActive = true
 	r.eventMu.Unlock()
-	
-	defer func() {
-		r.eventMu.Lock()
-		r.testActive = false
-		close(r.eventChan)
-		r.eventChan = nil
-		r.eventMu.Unlock()
-	}()
-
-	ticker := time.NewTicker(config.Interval)
-	defer ticker.Stop()
 
 	// Pending events buffer (events that occur between data points)
 	var pendingEvents []Event
@@ -177,60 +344,262 @@ func (r *Runner) RunTest(ctx context.Context, config TestConfig, updateChan chan
 
 	// Goroutine to collect events
 	go func() {
-		for evt := range r.eventChan {
+		for evt := range eventChan {
 			pendingEventsMu.Lock()
 			pendingEvents = append(pendingEvents, evt)
 			pendingEventsMu.Unlock()
 		}
 	}()
 
+	takeEvents = func() []Event {
+		pendingEventsMu.Lock()
+		defer pendingEventsMu.Unlock()
+		events := pendingEvents
+		pendingEvents = nil
+		return events
+	}
+	end = func() {
+		r.eventMu.Lock()
+		r.testActive = false
+		close(r.eventChan)
+		r.eventChan = nil
+		r.eventMu.Unlock()
+	}
+	return takeEvents, end
+}
+
+// RunTest starts a test and streams data points to the updateChan. With a
+// recorder set, the test is persisted while it runs, also if it fails.
+func (r *Runner) RunTest(ctx context.Context, config TestConfig, updateChan chan<- DataPoint) (*TestResult, error) {
+	result, err := r.runTest(ctx, config, updateChan)
+
+	// After-test hooks also run for a stopped test, e.g. to restore the DUT configuration
+	if result != nil {
+		testErr := result.AbortReason
+		if err != nil {
+			testErr = err.Error()
+		}
+		if hookErr := r.runHooks(context.Background(), result, HookAfterTest, "", testErr); hookErr != nil && err == nil {
+			err = hookErr
+		}
+	}
+
+	r.endRecording(result, err)
+	return result, err
+}
+
+func (r *Runner) runTest(ctx context.Context, config TestConfig, updateChan chan<- DataPoint) (*TestResult, error) {
+	if config.ReadTimeout <= 0 {
+		config.ReadTimeout = config.Interval
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = defaultRetryBackoff
+	}
+	r.resetMeters()
+	if config.Boot != nil {
+		return r.runBootTest(ctx, config, updateChan)
+	}
+	if config.Idle != nil {
+		return r.runIdleTest(ctx, config, updateChan)
+	}
+	if config.SteadyState != nil && config.SteadyState.Stability.Window <= 0 {
+		steady := *config.SteadyState
+		steady.Stability = DefaultStabilityCriterion()
+		config.SteadyState = &steady
+	}
+
+	result := &TestResult{
+		Config:     config,
+		DataPoints: make([]DataPoint, 0),
+		StartTime:  time.Now(),
+	}
+	r.beginRecording(result)
+
+	takeEvents, endTest := r.beginTest()
+	defer endTest()
+
+	if err := r.runHooks(ctx, result, HookBeforeTest, "", ""); err != nil {
+		result.EndTime = time.Now()
+		return result, err
+	}
+
+	// Phase extensions, load pauses and throughput changes through the control API
+	extendChan := r.beginControl()
+	defer r.endControl()
+
+	// Safety rules, evaluated on every tick until one fires
+	monitor := newAbortMonitor(config.Abort)
+	if monitor != nil && monitor.rules.TargetPort > 0 && config.LoadConfig.TargetIP != "" {
+		probeCtx, stopProbe := context.WithCancel(ctx)
+		defer stopProbe()
+		go monitor.probeTarget(probeCtx, r, config.LoadConfig.TargetIP, config.Interval)
+	}
+
+	// Throughput sampled at its own rate while load runs, independent of the meter
+	fastThroughput := newThroughputSampler(r, config.ThroughputInterval)
+	if fastThroughput != nil && config.LoadEnabled {
+		samplerCtx, stopSampler := context.WithCancel(ctx)
+		defer stopSampler()
+		go fastThroughput.run(samplerCtx)
+	} else {
+		fastThroughput = nil
+	}
+
+	ticker := time.NewTicker(config.Interval)
+	defer ticker.Stop()
+
+	// Last successful reading, held for a few intervals when reads fail
+	var lastGood fritzbox.Sample
+	var lastGoodTime time.Time
+
+	// Detects readings the meter has not refreshed since the previous tick
+	var refresh fritzbox.RefreshDetector
+
+	// Whether load generation is running and throughput should be recorded
+	loadRunning := false
+	// Interfaces generating load and when their throughput is checked by the safety rules
+	var loadActive map[string]time.Time
+
 	// Helper function to collect data for a phase
 	collectData := func(phaseDuration time.Duration, phase Phase, phaseStart bool) error {
 		if phaseDuration == 0 {
 			return nil
 		}
 
+		// Record the energy counter at both phase boundaries
+		boundary := PhaseBoundary{
+			Phase:         phase,
+			StartTime:     time.Now(),
+			StartEnergyWh: r.readEnergyCounter(ctx, config),
+		}
+		defer func() {
+			boundary.EndTime = time.Now()
+			boundary.EndEnergyWh = r.readEnergyCounter(ctx, config)
+			result.PhaseBoundaries = append(result.PhaseBoundaries, boundary)
+		}()
+		if fastThroughput != nil && loadRunning {
+			fastThroughput.start(phase)
+			defer func() {
+				fastThroughput.stop()
+				r.addThroughputSamples(result, fastThroughput.take())
+			}()
+		}
+
 		// Add phase change event
+		phaseNames := map[Phase]string{PhasePreTest: "Pre-Test Baseline", PhaseLoad: "Load Test", PhasePostTest: "Post-Test Baseline"}
+		if _, ok := phaseNames[phase]; !ok {
+			phaseNames[phase] = string(phase) // Test plan step label
+		}
 		if phaseStart {
-			phaseNames := map[Phase]string{PhasePreTest: "Pre-Test Baseline", PhaseLoad: "Load Test", PhasePostTest: "Post-Test Baseline"}
 			r.addEvent(EventPhaseChange, phaseNames[phase])
 		}
 
 		fmt.Printf("Starting %s phase (Duration: %s)\n", phase, phaseDuration)
 		timer := time.NewTimer(phaseDuration)
 		defer timer.Stop()
+		phaseEnd := time.Now().Add(phaseDuration)
+
+		// Steady-state gating: after the duration, end the phase once power is stable
+		phaseFrom := len(result.DataPoints)
+		minReached := false
+		var maxExtension <-chan time.Time
+		endIfStable := func() bool {
+			stable, _, reason := config.SteadyState.Stability.Evaluate(result.DataPoints[phaseFrom:], time.Now())
+			if stable {
+				boundary.EndReason = reason
+				r.addEvent(EventPhaseChange, fmt.Sprintf("%s ended: %s", phaseNames[phase], reason))
+				fmt.Printf("%s phase ended: %s\n", phase, reason)
+			}
+			return stable
+		}
 
 		for {
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
 			case <-timer.C:
+				if config.SteadyState == nil {
+					return nil
+				}
+				minReached = true
+				if endIfStable() {
+					return nil
+				}
+				extension := time.NewTimer(config.SteadyState.MaxExtension)
+				defer extension.Stop()
+				maxExtension = extension.C
+			case d := <-extendChan:
+				if minReached {
+					// Held open by steady-state gating: run d more, then check again
+					minReached = false
+					maxExtension = nil
+					phaseEnd = time.Now().Add(d)
+				} else {
+					phaseEnd = phaseEnd.Add(d)
+				}
+				timer.Reset(time.Until(phaseEnd))
+				r.addEvent(EventControl, fmt.Sprintf("%s extended by %s", phaseNames[phase], d))
+				fmt.Printf("%s phase extended by %s\n", phase, d)
+			case <-maxExtension:
+				_, _, reason := config.SteadyState.Stability.Evaluate(result.DataPoints[phaseFrom:], time.Now())
+				boundary.EndReason = fmt.Sprintf("not stable after %s extension: %s", config.SteadyState.MaxExtension, reason)
+				r.addEvent(EventPhaseChange, fmt.Sprintf("%s ended: %s", phaseNames[phase], boundary.EndReason))
+				fmt.Printf("%s phase ended: %s\n", phase, boundary.EndReason)
 				return nil
 			case t := <-ticker.C:
-				power, err := r.meter.GetCurrentPower()
+				sample, retries, powerByMeter, err := r.readMeters(ctx, config)
+				quality := QualityOK
+				if retries > 0 {
+					quality = QualityRetried
+				}
+				repeated := false
 				if err != nil {
+					if ctx.Err() != nil {
+						return ctx.Err()
+					}
 					fmt.Printf("Error reading power: %v\n", err)
-					continue
+					// Record the tick anyway so the gap is visible in the time series
+					if !lastGoodTime.IsZero() && t.Sub(lastGoodTime) <= staleHoldIntervals*config.Interval {
+						sample = fritzbox.Sample{PowerMW: lastGood.PowerMW}
+						quality = QualityStale
+					} else {
+						sample = fritzbox.Sample{}
+						quality = QualityMissing
+					}
+				} else {
+					lastGood, lastGoodTime = sample, t
+					repeated = refresh.Repeated(sample, t)
 				}
 
 				throughput := 0.0
 				var throughputByInterface map[string]float64
 			var targetThroughputByInterface map[string]float64
-			if phase == PhaseLoad && config.LoadEnabled {
+			if loadRunning {
 				throughput = r.loadGen.GetThroughput()
 				throughputByInterface = r.loadGen.GetThroughputByInterface()
 				targetThroughputByInterface = r.loadGen.GetTargetThroughputByInterface()
+				if fastThroughput != nil {
+					if samples := fastThroughput.take(); len(samples) > 0 {
+						r.addThroughputSamples(result, samples)
+						throughput, throughputByInterface = meanThroughput(samples)
+					}
+				}
 			}
 
 			// Collect pending events
-			pendingEventsMu.Lock()
-			events := pendingEvents
-			pendingEvents = nil
-			pendingEventsMu.Unlock()
+			events := takeEvents()
 
 			dp := DataPoint{
 				Timestamp:                   t,
-				PowerMW:                     power,
+				PowerMW:                     sample.PowerMW,
+				VoltageV:                    sample.VoltageV,
+				CurrentA:                    sample.CurrentA,
+				PowerFactor:                 sample.PowerFactor,
+				EnergyWh:                    sample.EnergyWh,
+				MeterTimestamp:              sample.MeterTimestamp,
+				PowerByMeter:                powerByMeter,
+				Quality:                     quality,
+				Repeated:                    repeated,
 				ThroughputMbps:              throughput,
 				ThroughputByInterface:       throughputByInterface,
 				TargetThroughputByInterface: targetThroughputByInterface,
@@ -238,32 +607,112 @@ func (r *Runner) RunTest(ctx context.Context, config TestConfig, updateChan chan
 				Events:                      events,
 			}
 
+			// A fired safety rule ends the phase, the abort event marks this data point
+			abortReason := ""
+			if monitor != nil && result.AbortReason == "" {
+				active := loadActive
+				if !loadRunning {
+					active = nil
+				}
+				abortReason = monitor.check(dp, err != nil, active, r.loadGen.IsPaused(), config.LoadConfig.TargetIP)
+				if abortReason != "" {
+					dp.Events = append(dp.Events, Event{Type: EventAbort, Message: "Test aborted: " + abortReason, Timestamp: t})
+				}
+			}
+
 			result.DataPoints = append(result.DataPoints, dp)
+			r.recordDataPoint(dp)
 
 			select {
 			case updateChan <- dp:
 			default:
 				}
+
+				if abortReason != "" {
+					result.AbortReason = abortReason
+					boundary.EndReason = "aborted: " + abortReason
+					fmt.Printf("Safety abort in %s phase: %s\n", phase, abortReason)
+					return nil
+				}
+
+				if minReached && endIfStable() {
+					return nil
+				}
 			}
 		}
 	}
 
 	fmt.Printf("Starting test: %s\n", config.Description)
 
+	if config.Plan != nil {
+		for i, step := range config.Plan.Steps {
+			// After a safety abort only a final baseline step still runs
+			if result.AbortReason != "" && (i < len(config.Plan.Steps)-1 || len(step.Interfaces) > 0) {
+				fmt.Printf("Plan step %d/%d skipped after safety abort: %s\n", i+1, len(config.Plan.Steps), step.Label)
+				continue
+			}
+			fmt.Printf("Plan step %d/%d: %s\n", i+1, len(config.Plan.Steps), step.Label)
+			if err := r.runHooks(ctx, result, HookPhaseChange, Phase(step.Label), ""); err != nil {
+				result.EndTime = time.Now()
+				return result, err
+			}
+			stopLoad := func() {}
+			if config.LoadEnabled && len(step.Interfaces) > 0 && (config.LoadConfig.TargetIP != "" || config.LoadConfig.TargetMAC != "") {
+				stopLoad = r.startStepLoad(ctx, config.LoadConfig, step)
+				loadRunning = true
+				loadActive = make(map[string]time.Time, len(step.Interfaces))
+				for _, pi := range step.Interfaces {
+					loadActive[interfaceKey(pi.Name)] = time.Now()
+				}
+			}
+			err := collectData(time.Duration(step.Duration), Phase(step.Label), true)
+			stopLoad()
+			loadRunning = false
+			if err != nil {
+				result.EndTime = time.Now()
+				return result, err
+			}
+		}
+
+		result.EndTime = time.Now()
+		fmt.Printf("Test plan completed. Total data points: %d\n", len(result.DataPoints))
+		return result, nil
+	}
+
 	// Phase 1: Pre-test baseline (no load)
 	if config.PreTestTime > 0 {
+		if err := r.runHooks(ctx, result, HookPhaseChange, PhasePreTest, ""); err != nil {
+			result.EndTime = time.Now()
+			return result, err
+		}
 		if err := collectData(config.PreTestTime, PhasePreTest, true); err != nil {
 			result.EndTime = time.Now()
 			return result, err
 		}
 	}
 
-	// Phase 2: Load test
+	// Phase 2: Load test, skipped after a safety abort in the pre-test baseline
+	loadDuration := config.Duration
+	if result.AbortReason != "" {
+		loadDuration = 0
+	}
+	if loadDuration > 0 {
+		if err := r.runHooks(ctx, result, HookPhaseChange, PhaseLoad, ""); err != nil {
+			result.EndTime = time.Now()
+			return result, err
+		}
+	}
 	var loadCancel context.CancelFunc
 	var loadCtx context.Context
-	if config.LoadEnabled && (config.LoadConfig.TargetIP != "" || config.LoadConfig.TargetMAC != "") {
+	if loadDuration > 0 && config.LoadEnabled && (config.LoadConfig.TargetIP != "" || config.LoadConfig.TargetMAC != "") {
 		loadCtx, loadCancel = context.WithCancel(ctx)
 
+		// Throughput is checked once an interface's pre-delay has passed
+		loadActive = make(map[string]time.Time, len(config.LoadConfig.InterfaceConfigs))
+		for _, ic := range config.LoadConfig.InterfaceConfigs {
+			loadActive[interfaceKey(ic.Name)] = time.Now().Add(ic.PreTime)
+		}
+
 		// Start interfaces with their individual pre-delays
 		for _, ic := range config.LoadConfig.InterfaceConfigs {
 			ifaceConfig := ic // capture for goroutine
@@ -303,7 +752,8 @@ func (r *Runner) RunTest(ctx context.Context, config TestConfig, updateChan chan
 		}
 	}
 
-	if err := collectData(config.Duration, PhaseLoad, true); err != nil {
+	loadRunning = config.LoadEnabled
+	if err := collectData(loadDuration, PhaseLoad, true); err != nil {
 		if loadCancel != nil {
 			loadCancel()
 		}
@@ -312,6 +762,7 @@ func (r *Runner) RunTest(ctx context.Context, config TestConfig, updateChan chan
 	}
 
 	// Stop load generation before post-test
+	loadRunning = false
 	if loadCancel != nil {
 		loadCancel()
 		time.Sleep(500 * time.Millisecond) // Allow load gen to stop cleanly
@@ -319,6 +770,10 @@ func (r *Runner) RunTest(ctx context.Context, config TestConfig, updateChan chan
 
 	// Phase 3: Post-test baseline (no load)
 	if config.PostTestTime > 0 {
+		if err := r.runHooks(ctx, result, HookPhaseChange, PhasePostTest, ""); err != nil {
+			result.EndTime = time.Now()
+			return result, err
+		}
 		if err := collectData(config.PostTestTime, PhasePostTest, true); err != nil {
 			result.EndTime = time.Now()
 			return result, err
@@ -330,6 +785,155 @@ func (r *Runner) RunTest(ctx context.Context, config TestConfig, updateChan chan
 	return result, nil
 }
 
+// readMeters reads the DUT meter and all additional meters concurrently so the
+// readings share the same instant. Failing additional meters are left out; the
+// others are also returned if the DUT read fails.
+// It returns the number of retries needed for the DUT meter.
+func (r *Runner) readMeters(ctx context.Context, config TestConfig) (fritzbox.Sample, int, map[string]float64, error) {
+	if len(r.extraMeters) == 0 {
+		sample, retries, err := readSample(ctx, r.meterReads, config)
+		return sample, retries, nil, err
+	}
+
+	var wg sync.WaitGroup
+	powers := make([]float64, len(r.extraMeters))
+	errs := make([]error, len(r.extraMeters))
+	for i, m := range r.extraMeters {
+		wg.Add(1)
+		go func(i int, reads *meterReader) {
+			defer wg.Done()
+			sample, _, err := readSample(ctx, reads, config)
+			powers[i], errs[i] = sample.PowerMW, err
+		}(i, m.reads)
+	}
+	sample, retries, err := readSample(ctx, r.meterReads, config)
+	wg.Wait()
+
+	powerByMeter := make(map[string]float64, len(r.extraMeters))
+	for i, m := range r.extraMeters {
+		if errs[i] != nil {
+			fmt.Printf("Error reading meter %s: %v\n", m.Name, errs[i])
+			continue
+		}
+		powerByMeter[m.Name] = powers[i]
+	}
+	if err != nil {
+		return fritzbox.Sample{}, retries, powerByMeter, err
+	}
+	return sample, retries, powerByMeter, nil
+}
+
+// readSample reads a meter with a per-attempt timeout and up to config.ReadRetries
+// retries with exponential backoff. It returns the number of retries used.
+func readSample(ctx context.Context, reads *meterReader, config TestConfig) (fritzbox.Sample, int, error) {
+	backoff := config.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		sample, err := reads.read(config.ReadTimeout)
+		if err == nil {
+			return sample, attempt, nil
+		}
+		if attempt >= config.ReadRetries {
+			return fritzbox.Sample{}, attempt, err
+		}
+
+		fmt.Printf("Power read failed (attempt %d of %d), retrying in %s: %v\n", attempt+1, config.ReadRetries+1, backoff, err)
+		select {
+		case <-ctx.Done():
+			return fritzbox.Sample{}, attempt, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// meterReader runs the reads of one meter one at a time. Meters are not safe
+// for concurrent use, and a read that timed out keeps running in the background,
+// so the next read waits for it to return before sending a new request.
+type meterReader struct {
+	meter fritzbox.PowerMeter
+
+	mu      sync.Mutex
+	pending chan struct{} // Closed when the running read returns, nil if none is running
+}
+
+// read gives up waiting for the meter after timeout (0 = no timeout), including
+// the time spent waiting for a previous read to return
+func (m *meterReader) read(timeout time.Duration) (fritzbox.Sample, error) {
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	m.mu.Lock()
+	for m.pending != nil {
+		pending := m.pending
+		m.mu.Unlock()
+		select {
+		case <-pending:
+		case <-expired:
+			return fritzbox.Sample{}, fmt.Errorf("power read timed out after %s waiting for the previous read", timeout)
+		}
+		m.mu.Lock()
+	}
+	done := make(chan struct{})
+	m.pending = done
+	m.mu.Unlock()
+
+	var sample fritzbox.Sample
+	var err error
+	go func() {
+		sample, err = fritzbox.ReadSample(m.meter)
+		m.mu.Lock()
+		m.pending = nil
+		m.mu.Unlock()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return sample, err
+	case <-expired:
+		return fritzbox.Sample{}, fmt.Errorf("power read timed out after %s", timeout)
+	}
+}
+
+// readEnergyCounter returns the meter's energy counter in Wh, or nil if unavailable
+func (r *Runner) readEnergyCounter(ctx context.Context, config TestConfig) *float64 {
+	sample, _, err := readSample(ctx, r.meterReads, config)
+	if err != nil {
+		fmt.Printf("Error reading energy counter: %v\n", err)
+		return nil
+	}
+	return sample.EnergyWh
+}
+
+// IntegrateEnergyWh integrates PowerMW over time using the trapezoidal rule.
+// If start/end are set, the first and last sample are held up to the phase boundaries
+// so the result covers the same time span as the energy counter delta.
+func IntegrateEnergyWh(points []DataPoint, start, end time.Time) float64 {
+	if len(points) == 0 {
+		return 0
+	}
+
+	var mWs float64 // milliwatt-seconds
+	for i := 1; i < len(points); i++ {
+		dt := points[i].Timestamp.Sub(points[i-1].Timestamp).Seconds()
+		mWs += (points[i-1].PowerMW + points[i].PowerMW) / 2 * dt
+	}
+
+	first, last := points[0], points[len(points)-1]
+	if !start.IsZero() && first.Timestamp.After(start) {
+		mWs += first.PowerMW * first.Timestamp.Sub(start).Seconds()
+	}
+	if !end.IsZero() && end.After(last.Timestamp) {
+		mWs += last.PowerMW * end.Sub(last.Timestamp).Seconds()
+	}
+
+	return mWs / 1000 / 3600 // mWs -> Wh
+}
+
 // runInterfaceRamping gradually increases throughput for a specific interface
 func (r *Runner) runInterfaceRamping(ctx context.Context, ic loadgen.InterfaceConfig) {
 	if ic.RampSteps <= 0 || ic.TargetThroughput <= 0 {
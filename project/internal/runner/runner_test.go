@@ -0,0 +1,122 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"project/internal/loadgen"
+)
+
+// slowMeter takes delay to answer and, like most backends, is not safe for
+// concurrent use: overlapping reads race on reads (run with -race)
+type slowMeter struct {
+	delay     time.Duration
+	reads     int
+	active    atomic.Int32
+	maxActive atomic.Int32
+}
+
+func (m *slowMeter) GetCurrentPower() (float64, error) {
+	n := m.active.Add(1)
+	defer m.active.Add(-1)
+	for {
+		max := m.maxActive.Load()
+		if n <= max || m.maxActive.CompareAndSwap(max, n) {
+			break
+		}
+	}
+	time.Sleep(m.delay)
+	m.reads++
+	return 1000, nil
+}
+
+func (m *slowMeter) TestConnection() error { return nil }
+
+func TestReadSampleOneReadPerMeter(t *testing.T) {
+	meter := &slowMeter{delay: 50 * time.Millisecond}
+	reads := &meterReader{meter: meter}
+	config := TestConfig{ReadTimeout: 20 * time.Millisecond, ReadRetries: 2, RetryBackoff: time.Millisecond}
+
+	// Reads time out while the meter is still busy; the retries and the
+	// concurrent readers must not send a second request in the meantime
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := readSample(context.Background(), reads, config); err == nil {
+				t.Error("read succeeded before the meter answered")
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Without a timeout the read waits for the pending one and then succeeds
+	sample, _, err := readSample(context.Background(), reads, TestConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sample.PowerMW != 1000 {
+		t.Errorf("PowerMW = %v, want 1000", sample.PowerMW)
+	}
+	if n := meter.maxActive.Load(); n != 1 {
+		t.Errorf("%d concurrent reads, want 1", n)
+	}
+	if meter.reads < 2 {
+		t.Errorf("reads = %d, want the timed out read and the final one", meter.reads)
+	}
+}
+
+// replayMeter counts how often its playback was restarted
+type replayMeter struct {
+	switchMeter
+	resets atomic.Int32
+}
+
+func (m *replayMeter) Reset() { m.resets.Add(1) }
+
+func TestRunTestResetsReplayedMeters(t *testing.T) {
+	dut, extra := &replayMeter{}, &replayMeter{}
+	r := NewRunner(dut, loadgen.NewNetworkLoadGenerator())
+	if err := r.AddMeter("pc", extra); err != nil {
+		t.Fatal(err)
+	}
+
+	config := TestConfig{Interval: 10 * time.Millisecond, PreTestTime: 20 * time.Millisecond}
+	for run := 1; run <= 2; run++ {
+		if _, err := r.RunTest(context.Background(), config, make(chan DataPoint, 100)); err != nil {
+			t.Fatal(err)
+		}
+		if dut.resets.Load() != int32(run) || extra.resets.Load() != int32(run) {
+			t.Errorf("run %d: resets dut %d, pc %d, want one per test", run, dut.resets.Load(), extra.resets.Load())
+		}
+	}
+}
+
+// failingMeter cannot be read
+type failingMeter struct{}
+
+func (failingMeter) GetCurrentPower() (float64, error) { return 0, errors.New("meter unreachable") }
+func (failingMeter) TestConnection() error             { return errors.New("meter unreachable") }
+
+func TestReadMetersKeepsOtherMetersWhenDUTFails(t *testing.T) {
+	r := NewRunner(failingMeter{}, loadgen.NewNetworkLoadGenerator())
+	if err := r.AddMeter("pc", switchMeter{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AddMeter("ap", failingMeter{}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, powerByMeter, err := r.readMeters(context.Background(), TestConfig{})
+	if err == nil {
+		t.Fatal("failed DUT read not reported")
+	}
+	if len(powerByMeter) != 1 || powerByMeter["pc"] != 1850 {
+		t.Errorf("power by meter = %v, want pc only", powerByMeter)
+	}
+}
@@ -0,0 +1,135 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// AbortRules stop a load test or test plan early when the DUT or the setup
+// misbehaves. Zero values disable a rule. When a rule fires, load generation
+// stops and the post-test baseline still runs.
+type AbortRules struct {
+	MaxPowerMW float64 // Abort on a valid reading above this power
+	MinPowerMW float64 // Abort on a valid reading below this power
+
+	// Abort if an interface stays below MinThroughputPercent of its target
+	// throughput for ThroughputGrace (default 10s). Unlimited targets are not checked.
+	MinThroughputPercent float64
+	ThroughputGrace      time.Duration
+
+	MaxFailedReads int // Abort after this many failed meter reads in a row
+
+	// Abort if LoadConfig.TargetIP stops accepting TCP connections on TargetPort
+	// for MaxTargetFailures (default 3) checks in a row, checked every poll interval
+	TargetPort        int
+	MaxTargetFailures int
+}
+
+const (
+	// defaultThroughputGrace is used when AbortRules.ThroughputGrace is not set
+	defaultThroughputGrace = 10 * time.Second
+	// defaultMaxTargetFailures is used when AbortRules.MaxTargetFailures is not set
+	defaultMaxTargetFailures = 3
+)
+
+// abortMonitor evaluates the abort rules on every tick
+type abortMonitor struct {
+	rules          AbortRules
+	failedReads    int                  // Consecutive failed meter reads
+	belowSince     map[string]time.Time // Interfaces below the throughput limit, since when
+	targetFailures atomic.Int32         // Consecutive failed target checks, written by the probe
+}
+
+// newAbortMonitor returns nil if rules is nil
+func newAbortMonitor(rules *AbortRules) *abortMonitor {
+	if rules == nil {
+		return nil
+	}
+	m := &abortMonitor{rules: *rules, belowSince: make(map[string]time.Time)}
+	if m.rules.ThroughputGrace <= 0 {
+		m.rules.ThroughputGrace = defaultThroughputGrace
+	}
+	if m.rules.MaxTargetFailures <= 0 {
+		m.rules.MaxTargetFailures = defaultMaxTargetFailures
+	}
+	return m
+}
+
+// probeTarget checks every interval whether targetIP accepts connections on
+// the rule's target port, until ctx is done
+func (m *abortMonitor) probeTarget(ctx context.Context, r *Runner, targetIP string, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if r.TestTargetConnection(targetIP, m.rules.TargetPort) != nil {
+			m.targetFailures.Add(1)
+		} else {
+			m.targetFailures.Store(0)
+		}
+	}
+}
+
+// check evaluates the rules on a new data point and returns why the test must
+// be aborted, or "" to continue. loadActive maps the interfaces generating
+// load to the time their throughput is checked from; it is nil without load.
+func (m *abortMonitor) check(dp DataPoint, readFailed bool, loadActive map[string]time.Time, loadPaused bool, targetIP string) string {
+	if readFailed {
+		m.failedReads++
+		if m.rules.MaxFailedReads > 0 && m.failedReads >= m.rules.MaxFailedReads {
+			return fmt.Sprintf("meter unreachable for %d reads", m.failedReads)
+		}
+	} else {
+		m.failedReads = 0
+		if m.rules.MaxPowerMW > 0 && dp.PowerMW > m.rules.MaxPowerMW {
+			return fmt.Sprintf("power %.0f mW above the limit of %.0f mW", dp.PowerMW, m.rules.MaxPowerMW)
+		}
+		if m.rules.MinPowerMW > 0 && dp.PowerMW < m.rules.MinPowerMW {
+			return fmt.Sprintf("power %.0f mW below the limit of %.0f mW", dp.PowerMW, m.rules.MinPowerMW)
+		}
+	}
+
+	if m.rules.TargetPort > 0 {
+		if n := int(m.targetFailures.Load()); n >= m.rules.MaxTargetFailures {
+			return fmt.Sprintf("target %s:%d unreachable for %d checks", targetIP, m.rules.TargetPort, n)
+		}
+	}
+
+	if m.rules.MinThroughputPercent <= 0 || loadActive == nil || loadPaused {
+		clear(m.belowSince)
+		return ""
+	}
+	names := make([]string, 0, len(loadActive))
+	for name := range loadActive {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		target := dp.TargetThroughputByInterface[name]
+		measured := dp.ThroughputByInterface[name]
+		if dp.Timestamp.Before(loadActive[name]) || target <= 0 || measured >= target*m.rules.MinThroughputPercent/100 {
+			delete(m.belowSince, name)
+			continue
+		}
+		since, ok := m.belowSince[name]
+		if !ok {
+			since = dp.Timestamp
+			m.belowSince[name] = since
+		}
+		if below := dp.Timestamp.Sub(since); below >= m.rules.ThroughputGrace {
+			return fmt.Sprintf("[%s] throughput %.1f Mbps below %.0f%% of the %.1f Mbps target for %s",
+				name, measured, m.rules.MinThroughputPercent, target, below.Round(time.Second))
+		}
+	}
+	return ""
+}
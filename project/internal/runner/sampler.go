@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"project/internal/fritzbox"
+)
+
+// sampler reads the DUT meter into the data points of a passive test (boot or
+// idle), i.e. without load generation or throughput columns
+type sampler struct {
+	r          *Runner
+	ctx        context.Context
+	config     TestConfig
+	result     *TestResult
+	updateChan chan<- DataPoint
+	takeEvents func() []Event
+	refresh    fritzbox.RefreshDetector
+}
+
+// sample reads the meter once and appends the data point with the pending
+// events and extra. Failed reads are recorded as missing; only cancellation
+// returns an error.
+func (s *sampler) sample(phase Phase, extra ...Event) error {
+	sample, retries, err := readSample(s.ctx, s.r.meterReads, s.config)
+	dp := DataPoint{
+		Timestamp: time.Now(),
+		Phase:     phase,
+		Quality:   QualityOK,
+	}
+	if err != nil {
+		if s.ctx.Err() != nil {
+			return s.ctx.Err()
+		}
+		fmt.Printf("Error reading power: %v\n", err)
+		dp.Quality = QualityMissing
+	} else {
+		if retries > 0 {
+			dp.Quality = QualityRetried
+		}
+		dp.PowerMW = sample.PowerMW
+		dp.VoltageV = sample.VoltageV
+		dp.CurrentA = sample.CurrentA
+		dp.PowerFactor = sample.PowerFactor
+		dp.EnergyWh = sample.EnergyWh
+		dp.MeterTimestamp = sample.MeterTimestamp
+		dp.Repeated = s.refresh.Repeated(sample, dp.Timestamp)
+	}
+	dp.Events = append(s.takeEvents(), extra...)
+
+	s.result.DataPoints = append(s.result.DataPoints, dp)
+	s.r.recordDataPoint(dp)
+	select {
+	case s.updateChan <- dp:
+	default:
+	}
+	return nil
+}
+
+// sampleUntil samples every config.Interval (back-to-back if zero) until stop
+// is closed or done returns true after a sample. stop and done may be nil.
+func (s *sampler) sampleUntil(phase Phase, stop <-chan struct{}, done func() bool) error {
+	for {
+		next := time.Now().Add(s.config.Interval)
+		if err := s.sample(phase); err != nil {
+			return err
+		}
+		if done != nil && done() {
+			return nil
+		}
+
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+		case <-stop:
+			return nil
+		case <-time.After(time.Until(next)):
+		}
+	}
+}
+
+// closeAfter returns a channel that is closed after d and a function to cancel the timer
+func closeAfter(d time.Duration) (<-chan struct{}, func() bool) {
+	done := make(chan struct{})
+	timer := time.AfterFunc(d, func() { close(done) })
+	return done, timer.Stop
+}
@@ -0,0 +1,94 @@
+package runner
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// StabilityCriterion decides whether PowerMW has settled, evaluated over the
+// valid samples of a rolling window. Repeated readings count: a meter holding
+// its value confirms that the power did not change. Unset thresholds are not checked.
+type StabilityCriterion struct {
+	Window           time.Duration // Rolling window length
+	MaxStdDevMW      float64       // Maximum standard deviation within the window
+	MaxSlopeMWPerMin float64       // Maximum absolute slope of a linear fit within the window
+}
+
+// DefaultStabilityCriterion accepts a drift of up to 50 mW per minute over two minutes
+func DefaultStabilityCriterion() StabilityCriterion {
+	return StabilityCriterion{
+		Window:           2 * time.Minute,
+		MaxSlopeMWPerMin: 50,
+	}
+}
+
+// WindowStats describes the samples of one stability window
+type WindowStats struct {
+	Samples       int
+	MeanMW        float64
+	StdDevMW      float64
+	SlopeMWPerMin float64
+}
+
+// Evaluate checks the window ending at now. points must cover at least the
+// whole window (the first sample must be at or before now-Window), otherwise
+// the power is not considered stable yet. The returned reason describes the result.
+func (c StabilityCriterion) Evaluate(points []DataPoint, now time.Time) (bool, WindowStats, string) {
+	windowStart := now.Add(-c.Window)
+	if len(points) == 0 || points[0].Timestamp.After(windowStart) {
+		return false, WindowStats{}, "window not filled yet"
+	}
+
+	var window []DataPoint
+	for _, dp := range points {
+		if dp.Valid() && !dp.Timestamp.Before(windowStart) && !dp.Timestamp.After(now) {
+			window = append(window, dp)
+		}
+	}
+	if len(window) < 3 {
+		return false, WindowStats{Samples: len(window)}, "too few samples in window"
+	}
+
+	stats := windowStats(window)
+	if c.MaxStdDevMW > 0 && stats.StdDevMW > c.MaxStdDevMW {
+		return false, stats, fmt.Sprintf("std dev %.0f mW above %.0f mW", stats.StdDevMW, c.MaxStdDevMW)
+	}
+	if c.MaxSlopeMWPerMin > 0 && math.Abs(stats.SlopeMWPerMin) > c.MaxSlopeMWPerMin {
+		return false, stats, fmt.Sprintf("slope %.0f mW/min above %.0f mW/min", stats.SlopeMWPerMin, c.MaxSlopeMWPerMin)
+	}
+	return true, stats, fmt.Sprintf("stable over %s (std dev %.0f mW, slope %.0f mW/min)",
+		c.Window, stats.StdDevMW, stats.SlopeMWPerMin)
+}
+
+// windowStats calculates mean, standard deviation and least-squares slope
+func windowStats(points []DataPoint) WindowStats {
+	n := float64(len(points))
+	t0 := points[0].Timestamp
+
+	var sumT, sumP float64
+	for _, dp := range points {
+		sumT += dp.Timestamp.Sub(t0).Minutes()
+		sumP += dp.PowerMW
+	}
+	meanT, meanP := sumT/n, sumP/n
+
+	var varT, varP, cov float64
+	for _, dp := range points {
+		dt := dp.Timestamp.Sub(t0).Minutes() - meanT
+		dP := dp.PowerMW - meanP
+		varT += dt * dt
+		varP += dP * dP
+		cov += dt * dP
+	}
+
+	stats := WindowStats{
+		Samples:  len(points),
+		MeanMW:   meanP,
+		StdDevMW: math.Sqrt(varP / n),
+	}
+	if varT > 0 {
+		stats.SlopeMWPerMin = cov / varT
+	}
+	return stats
+}
@@ -0,0 +1,161 @@
+package runner
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"project/internal/fritzbox"
+	"project/internal/loadgen"
+)
+
+// steadyPoints returns n data points of constant power, one per interval,
+// where all but every refreshEvery-th reading are repeated
+func steadyPoints(start time.Time, n int, interval time.Duration, powerMW float64, refreshEvery int, phase Phase) []DataPoint {
+	points := make([]DataPoint, n)
+	for i := range points {
+		points[i] = DataPoint{
+			Timestamp: start.Add(time.Duration(i) * interval),
+			PowerMW:   powerMW,
+			Quality:   QualityOK,
+			Repeated:  i%refreshEvery != 0,
+			Phase:     phase,
+		}
+	}
+	return points
+}
+
+func TestEvaluateRepeatedReadingsConfirmStability(t *testing.T) {
+	// A slow meter refreshing once per window still shows a stable DUT
+	start := time.Now()
+	points := steadyPoints(start, 150, time.Second, 1850, 200, PhaseLoad)
+	now := points[len(points)-1].Timestamp
+
+	stable, stats, reason := DefaultStabilityCriterion().Evaluate(points, now)
+	if !stable {
+		t.Fatalf("constant power not stable: %s", reason)
+	}
+	if stats.Samples < 100 {
+		t.Errorf("Samples = %d, want the whole window", stats.Samples)
+	}
+}
+
+func TestEvaluateIgnoresInvalidReadings(t *testing.T) {
+	start := time.Now()
+	points := steadyPoints(start, 150, time.Second, 1850, 1, PhaseLoad)
+	for i := range points {
+		if i%2 == 0 {
+			points[i].Quality = QualityMissing
+			points[i].PowerMW = 0
+		}
+	}
+	now := points[len(points)-1].Timestamp
+
+	if stable, _, reason := DefaultStabilityCriterion().Evaluate(points, now); !stable {
+		t.Fatalf("missing readings broke stability: %s", reason)
+	}
+}
+
+func TestEvaluateDrift(t *testing.T) {
+	start := time.Now()
+	points := steadyPoints(start, 150, time.Second, 0, 1, PhaseLoad)
+	for i := range points {
+		points[i].PowerMW = 2000 + float64(i)*5 // 300 mW/min
+	}
+	now := points[len(points)-1].Timestamp
+
+	if stable, _, _ := DefaultStabilityCriterion().Evaluate(points, now); stable {
+		t.Fatal("drifting power considered stable")
+	}
+}
+
+func TestCalculateIdleResultKeepsRepeatedReadings(t *testing.T) {
+	start := time.Now()
+	result := &TestResult{
+		DataPoints: steadyPoints(start, 100, time.Second, 1850, 10, PhaseIdle),
+		Idle:       &IdleResult{},
+	}
+	calculateIdleResult(result)
+
+	if result.Idle.SampleCount != 100 {
+		t.Errorf("SampleCount = %d, want 100", result.Idle.SampleCount)
+	}
+	if result.Idle.MeanPowerMW != 1850 {
+		t.Errorf("MeanPowerMW = %v, want 1850", result.Idle.MeanPowerMW)
+	}
+	if result.Idle.CI95LowMW == nil || result.Idle.CI95HighMW == nil {
+		t.Error("confidence interval not computed")
+	}
+}
+
+func TestIdleConfigValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		window   time.Duration
+		interval time.Duration
+		duration time.Duration
+		err      string // Empty = valid
+	}{
+		{"fast poll", 2 * time.Minute, time.Second, time.Minute, ""},
+		{"60s poll, default window", 2 * time.Minute, time.Minute, time.Hour, "stability window"},
+		{"60s poll, derived window", IdleStabilityWindow(time.Minute), time.Minute, MinIdleDuration(time.Minute), ""},
+		{"60s poll, short measurement", 10 * time.Minute, time.Minute, time.Minute, "idle measurement"},
+	}
+	for _, tt := range tests {
+		idle := IdleConfig{Stability: StabilityCriterion{Window: tt.window, MaxSlopeMWPerMin: 50}}
+		err := idle.Validate(tt.interval, tt.duration)
+		if tt.err == "" && err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		} else if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%s: error %v, want %q", tt.name, err, tt.err)
+		}
+	}
+
+	// The derived defaults keep the 2 min window at fast polls
+	if w := IdleStabilityWindow(time.Second); w != 2*time.Minute {
+		t.Errorf("window at 1s = %s, want 2m", w)
+	}
+	if w := IdleStabilityWindow(time.Minute); w != 10*time.Minute {
+		t.Errorf("window at 60s = %s, want 10m", w)
+	}
+}
+
+// heldMeter reports constant power with a meter timestamp that never
+// advances, so every reading after the first is flagged as repeated
+type heldMeter struct {
+	at time.Time
+}
+
+func (m heldMeter) GetCurrentPower() (float64, error) { return 1850, nil }
+func (m heldMeter) TestConnection() error             { return nil }
+func (m heldMeter) GetSample() (fritzbox.Sample, error) {
+	return fritzbox.Sample{PowerMW: 1850, MeterTimestamp: &m.at}, nil
+}
+
+func TestSteadyStateGatingConstantMeter(t *testing.T) {
+	r := NewRunner(heldMeter{at: time.Now()}, loadgen.NewNetworkLoadGenerator())
+	config := TestConfig{
+		Interval:    10 * time.Millisecond,
+		PreTestTime: 300 * time.Millisecond,
+		SteadyState: &SteadyStateConfig{
+			Stability:    StabilityCriterion{Window: 200 * time.Millisecond, MaxSlopeMWPerMin: 50},
+			MaxExtension: 10 * time.Second,
+		},
+	}
+
+	start := time.Now()
+	result, err := r.RunTest(context.Background(), config, make(chan DataPoint, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed >= config.SteadyState.MaxExtension {
+		t.Fatalf("phase ran into the max extension (%s)", elapsed)
+	}
+	if len(result.PhaseBoundaries) == 0 {
+		t.Fatal("no phase boundary recorded")
+	}
+	if reason := result.PhaseBoundaries[0].EndReason; !strings.HasPrefix(reason, "stable over") {
+		t.Errorf("EndReason = %q, want stable", reason)
+	}
+}
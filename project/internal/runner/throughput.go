@@ -0,0 +1,156 @@
+package runner
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MinThroughputInterval is the shortest supported throughput sampling interval
+const MinThroughputInterval = 100 * time.Millisecond
+
+// ThroughputSample is a throughput reading taken every TestConfig.ThroughputInterval
+// while load runs, independent of the power meter's poll interval
+type ThroughputSample struct {
+	Timestamp                   time.Time          `json:"timestamp"`
+	ThroughputMbps              float64            `json:"throughput_mbps"`
+	ThroughputByInterface       map[string]float64 `json:"throughput_by_interface,omitempty"`
+	TargetThroughputByInterface map[string]float64 `json:"target_throughput_by_interface,omitempty"`
+	Phase                       Phase              `json:"phase"`
+}
+
+// SetThroughputUpdates streams the throughput samples of all following tests
+// to ch; samples are dropped while ch is not ready. It must not be called
+// while a test is running.
+func (r *Runner) SetThroughputUpdates(ch chan<- ThroughputSample) {
+	r.throughputUpdates = ch
+}
+
+// throughputSampler turns the load generator's byte counters into throughput
+// samples. Each sample is the mean rate since the previous one, so no traffic
+// between samples is missed.
+type throughputSampler struct {
+	r        *Runner
+	interval time.Duration
+
+	mu       sync.Mutex
+	active   bool // Sampling a phase with load
+	phase    Phase
+	last     map[string]uint64 // Byte counters at lastTime
+	lastTime time.Time
+	pending  []ThroughputSample // Taken since the last call of take
+}
+
+// newThroughputSampler returns nil if interval is not set
+func newThroughputSampler(r *Runner, interval time.Duration) *throughputSampler {
+	if interval <= 0 {
+		return nil
+	}
+	if interval < MinThroughputInterval {
+		interval = MinThroughputInterval
+	}
+	return &throughputSampler{r: r, interval: interval}
+}
+
+// run samples every interval until ctx is done
+func (s *throughputSampler) run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case t := <-ticker.C:
+			s.sample(t)
+		}
+	}
+}
+
+// start samples the phase until stop is called
+func (s *throughputSampler) start(phase Phase) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active = true
+	s.phase = phase
+	s.last = s.r.loadGen.GetBytesSentByInterface()
+	s.lastTime = time.Now()
+}
+
+// stop ends sampling until the next start
+func (s *throughputSampler) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active = false
+}
+
+// take returns the samples taken since its last call
+func (s *throughputSampler) take() []ThroughputSample {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	samples := s.pending
+	s.pending = nil
+	return samples
+}
+
+func (s *throughputSampler) sample(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.active {
+		return
+	}
+	elapsed := now.Sub(s.lastTime).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+
+	counters := s.r.loadGen.GetBytesSentByInterface()
+	sample := ThroughputSample{
+		Timestamp:                   now,
+		ThroughputByInterface:       make(map[string]float64, len(counters)),
+		TargetThroughputByInterface: s.r.loadGen.GetTargetThroughputByInterface(),
+		Phase:                       s.phase,
+	}
+	for name, bytes := range counters {
+		prev := s.last[name]
+		if bytes < prev {
+			prev = 0 // Counters carry over restarts, a lower value is a new counter
+		}
+		mbps := float64(bytes-prev) * 8 / (elapsed * 1_000_000)
+		sample.ThroughputByInterface[name] = mbps
+		sample.ThroughputMbps += mbps
+	}
+	s.last, s.lastTime = counters, now
+	s.pending = append(s.pending, sample)
+
+	select {
+	case s.r.throughputUpdates <- sample:
+	default:
+	}
+}
+
+// addThroughputSamples appends samples to the result and records them
+func (r *Runner) addThroughputSamples(result *TestResult, samples []ThroughputSample) {
+	if len(samples) == 0 {
+		return
+	}
+	result.ThroughputSamples = append(result.ThroughputSamples, samples...)
+	r.recordThroughputSamples(samples)
+}
+
+// meanThroughput averages samples into the throughput of a data point, which
+// then covers the same interval as the power reading
+func meanThroughput(samples []ThroughputSample) (float64, map[string]float64) {
+	var total float64
+	byInterface := make(map[string]float64)
+	for _, s := range samples {
+		total += s.ThroughputMbps
+		for name, mbps := range s.ThroughputByInterface {
+			byInterface[name] += mbps
+		}
+	}
+	n := float64(len(samples))
+	for name := range byInterface {
+		byInterface[name] /= n
+	}
+	return total / n, byInterface
+}
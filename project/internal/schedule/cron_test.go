@@ -0,0 +1,95 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCronErrors(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"0 22 * *", "needs 5 fields"},
+		{"60 * * * *", "minute"},
+		{"* 24 * * *", "hour"},
+		{"* * 0 * *", "day of month"},
+		{"* * * 13 *", "month"},
+		{"* * * * 8", "day of week"},
+		{"*/0 * * * *", "invalid step"},
+		{"5-1 * * * *", "out of range"},
+		{"a * * * *", "invalid value"},
+		{"1-x * * * *", "invalid range"},
+		{"0 0 30 2 *", "never matches"},
+		{"0 0 31 4,6,9,11 *", "never matches"},
+		{"0 0 30-31 2 *", "never matches"},
+	}
+	for _, tt := range tests {
+		if _, err := ParseCron(tt.expr); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%q: err = %v, want %q", tt.expr, err, tt.want)
+		}
+	}
+
+	// Days missing in some selected months, or a day of week alternative, still match
+	for _, expr := range []string{"0 0 29 2 *", "0 0 31 1-12 *", "0 0 30 2 1"} {
+		if _, err := ParseCron(expr); err != nil {
+			t.Errorf("%q: %v", expr, err)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	// Friday, 16 October 2026
+	from := time.Date(2026, 10, 16, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"every minute", "* * * * *", from, time.Date(2026, 10, 16, 10, 8, 0, 0, time.UTC)},
+		{"step", "*/15 * * * *", from, time.Date(2026, 10, 16, 10, 15, 0, 0, time.UTC)},
+		{"step from value", "50/20 * * * *", from, time.Date(2026, 10, 16, 10, 50, 0, 0, time.UTC)},
+		{"range with step", "0 9-17/4 * * *", from, time.Date(2026, 10, 16, 13, 0, 0, 0, time.UTC)},
+		{"list", "0 8,22 * * *", from, time.Date(2026, 10, 16, 22, 0, 0, 0, time.UTC)},
+		{"strictly after", "0 22 * * *", time.Date(2026, 10, 16, 22, 0, 0, 0, time.UTC), time.Date(2026, 10, 17, 22, 0, 0, 0, time.UTC)},
+		{"weekdays", "0 22 * * 1-5", time.Date(2026, 10, 16, 23, 0, 0, 0, time.UTC), time.Date(2026, 10, 19, 22, 0, 0, 0, time.UTC)},
+		{"7 is Sunday", "0 12 * * 7", from, time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)},
+		{"0 is Sunday", "0 12 * * 0", from, time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)},
+		{"day of month", "0 0 1 * *", from, time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"year wrap", "30 6 1 1 *", from, time.Date(2027, 1, 1, 6, 30, 0, 0, time.UTC)},
+		// Restricted day of month and day of week: either one matches
+		{"dom or dow, dow first", "0 0 13 * 1", from, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		{"dom or dow, dom first", "0 0 18 * 3", from, time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", from, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"31st skips short months", "0 0 31 * *", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := c.Next(tt.from); !got.Equal(tt.want) {
+			t.Errorf("%s: Next(%s) = %s, want %s", tt.name, tt.from, got, tt.want)
+		}
+	}
+}
+
+func TestCronNextLocation(t *testing.T) {
+	loc := time.FixedZone("CET", 3600)
+	c, err := ParseCron("0 22 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := c.Next(time.Date(2026, 10, 16, 20, 0, 0, 0, time.UTC)) // 21:00 CET
+	if want := time.Date(2026, 10, 16, 22, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("UTC: got %s, want %s", got, want)
+	}
+	got = c.Next(time.Date(2026, 10, 16, 21, 0, 0, 0, loc))
+	if want := time.Date(2026, 10, 16, 22, 0, 0, 0, loc); !got.Equal(want) || got.Location() != loc {
+		t.Errorf("CET: got %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,224 @@
+package server
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"project/internal/database"
+	"project/internal/runner"
+)
+
+// campaignRow is one run in the combined result table of a test matrix campaign
+type campaignRow struct {
+	TestID                int64    `json:"test_id"`
+	Protocol              string   `json:"protocol"`
+	PacketSize            int      `json:"packet_size"`
+	Ports                 int      `json:"ports"`
+	BaselinePowerMW       *float64 `json:"baseline_power_mw,omitempty"` // Pre-test average, or post-test if there is none
+	LoadPowerMW           float64  `json:"load_power_mw"`
+	DeltaPowerMW          *float64 `json:"delta_power_mw,omitempty"` // Load minus baseline
+	AverageThroughputMbps float64  `json:"average_throughput_mbps"`
+	LoadEnergyWh          float64  `json:"load_energy_wh"`
+	MWPerMbps             *float64 `json:"mw_per_mbps,omitempty"` // Delta power per Mbps of throughput
+}
+
+// parseMatrixConfig reads the matrix_* form values, e.g. matrix_packet_sizes=64,512,1472
+func parseMatrixConfig(form url.Values) (runner.MatrixConfig, error) {
+	var m runner.MatrixConfig
+	var err error
+	if m.PacketSizes, err = parseIntList(form.Get("matrix_packet_sizes")); err != nil {
+		return m, fmt.Errorf("invalid packet sizes: %w", err)
+	}
+	if m.PortCounts, err = parseIntList(form.Get("matrix_port_counts")); err != nil {
+		return m, fmt.Errorf("invalid port counts: %w", err)
+	}
+	for _, protocol := range strings.Split(form.Get("matrix_protocols"), ",") {
+		if protocol = strings.TrimSpace(protocol); protocol != "" {
+			m.Protocols = append(m.Protocols, protocol)
+		}
+	}
+	m.Cooldown, _ = time.ParseDuration(form.Get("matrix_cooldown"))
+	return m, nil
+}
+
+// parseIntList parses a comma-separated list of integers
+func parseIntList(s string) ([]int, error) {
+	var values []int
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		v, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// runSeries runs matrix runs and repetitions, each saved as its own record by
+// the recorder, and logs the combined result table and the group aggregates
+// at the end
+func (s *Server) runSeries(ctx context.Context, runs []runner.MatrixRun, gap time.Duration, updateChan chan<- runner.DataPoint) error {
+	log.Printf("Starting series of %d runs", len(runs))
+
+	var groupIDs []string
+	err := s.runner.RunSeries(ctx, runs, gap, updateChan, func(run runner.MatrixRun, result *runner.TestResult) {
+		log.Printf("Run %d/%d finished. Collected %d data points.", run.Index+1, len(runs), len(result.DataPoints))
+		if id := run.Config.GroupID; id != "" && (len(groupIDs) == 0 || groupIDs[len(groupIDs)-1] != id) {
+			groupIDs = append(groupIDs, id)
+		}
+	})
+	if err != nil {
+		log.Printf("Series stopped: %v", err)
+	}
+	if s.db == nil {
+		return err
+	}
+
+	if campaignID := runs[0].Config.CampaignID; campaignID != "" {
+		rows, tableErr := s.campaignTable(campaignID)
+		if tableErr != nil {
+			log.Printf("Failed to build campaign table: %v", tableErr)
+		} else {
+			var table strings.Builder
+			writeCampaignCSV(&table, rows)
+			log.Printf("Campaign %s completed (%d of %d runs):\n%s", campaignID, len(rows), len(runs), table.String())
+		}
+	}
+
+	for _, groupID := range groupIDs {
+		aggregate, aggErr := s.groupAggregate(groupID)
+		if aggErr != nil {
+			log.Printf("Failed to aggregate group %s: %v", groupID, aggErr)
+			continue
+		}
+		logGroupAggregate(aggregate)
+	}
+	return err
+}
+
+// campaignTable builds the combined result table from the saved runs of a campaign
+func (s *Server) campaignTable(campaignID string) ([]campaignRow, error) {
+	records, err := s.db.ListTestsByCampaign(campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]campaignRow, 0, len(records))
+	for _, record := range records {
+		if record.Status != database.TestCompleted {
+			continue // Partial runs would distort the comparison
+		}
+		var config runner.TestConfig
+		if err := json.Unmarshal([]byte(record.Config), &config); err != nil {
+			return nil, fmt.Errorf("failed to parse config of test %d: %w", record.ID, err)
+		}
+		var summary database.TestSummary
+		if err := json.Unmarshal([]byte(record.Summary), &summary); err != nil {
+			return nil, fmt.Errorf("failed to parse summary of test %d: %w", record.ID, err)
+		}
+
+		row := campaignRow{TestID: record.ID}
+		if p := config.MatrixParams; p != nil {
+			row.Protocol, row.PacketSize, row.Ports = p.Protocol, p.PacketSize, p.Ports
+		}
+		load := summary.PhaseStats[string(runner.PhaseLoad)]
+		row.LoadPowerMW = load.AveragePowerMW
+		row.AverageThroughputMbps = load.AverageThroughputMbps
+		row.LoadEnergyWh = load.EnergyIntegratedWh
+
+		if baseline, ok := baselineStats(&summary); ok {
+			baselinePower := baseline.AveragePowerMW
+			delta := row.LoadPowerMW - baselinePower
+			row.BaselinePowerMW = &baselinePower
+			row.DeltaPowerMW = &delta
+			if row.AverageThroughputMbps > 0 {
+				perMbps := delta / row.AverageThroughputMbps
+				row.MWPerMbps = &perMbps
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// writeCampaignCSV writes the combined result table as CSV
+func writeCampaignCSV(w io.Writer, rows []campaignRow) error {
+	optional := func(v *float64) string {
+		if v == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*v, 'f', 1, 64)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"Test_ID", "Protocol", "Packet_Size", "Ports", "Baseline_Power_MW", "Load_Power_MW",
+		"Delta_Power_MW", "Throughput_Mbps", "Load_Energy_Wh", "MW_Per_Mbps"})
+	for _, row := range rows {
+		cw.Write([]string{
+			strconv.FormatInt(row.TestID, 10),
+			row.Protocol,
+			strconv.Itoa(row.PacketSize),
+			strconv.Itoa(row.Ports),
+			optional(row.BaselinePowerMW),
+			strconv.FormatFloat(row.LoadPowerMW, 'f', 1, 64),
+			optional(row.DeltaPowerMW),
+			strconv.FormatFloat(row.AverageThroughputMbps, 'f', 1, 64),
+			strconv.FormatFloat(row.LoadEnergyWh, 'f', 4, 64),
+			optional(row.MWPerMbps),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// handleGetCampaign returns the combined result table of a campaign as JSON,
+// or as CSV with ?format=csv
+func (s *Server) handleGetCampaign(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.db == nil {
+		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	campaignID := strings.TrimPrefix(r.URL.Path, "/campaigns/")
+	if campaignID == "" {
+		http.Error(w, "Missing campaign ID", http.StatusBadRequest)
+		return
+	}
+
+	rows, err := s.campaignTable(campaignID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(rows) == 0 {
+		http.Error(w, "Campaign not found", http.StatusNotFound)
+		return
+	}
+
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=campaign_%s.csv", campaignID))
+		writeCampaignCSV(w, rows)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rows)
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"project/internal/runner"
+)
+
+// writeControlResult answers a control request, with 409 if no test can be controlled
+func writeControlResult(w http.ResponseWriter, err error, message string) {
+	if errors.Is(err, runner.ErrNotControllable) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(message))
+}
+
+// handleControlState returns whether the running test can be controlled and its load settings
+func (s *Server) handleControlState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.runner.ControlState())
+}
+
+// handleSetThroughput changes the target throughput of one interface of the running test
+func (s *Server) handleSetThroughput(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	mbps, err := strconv.ParseFloat(r.FormValue("throughput_mbps"), 64)
+	if err != nil {
+		http.Error(w, "Invalid throughput", http.StatusBadRequest)
+		return
+	}
+	err = s.runner.SetInterfaceThroughput(r.FormValue("interface"), mbps)
+	writeControlResult(w, err, "Throughput updated")
+}
+
+// handlePauseLoad pauses load generation while power sampling continues
+func (s *Server) handlePauseLoad(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeControlResult(w, s.runner.PauseLoad(), "Load paused")
+}
+
+// handleResumeLoad resumes paused load generation
+func (s *Server) handleResumeLoad(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeControlResult(w, s.runner.ResumeLoad(), "Load resumed")
+}
+
+// handleExtendPhase extends the current phase by the given duration
+func (s *Server) handleExtendPhase(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	d, err := time.ParseDuration(r.FormValue("duration"))
+	if err != nil {
+		http.Error(w, "Invalid duration", http.StatusBadRequest)
+		return
+	}
+	writeControlResult(w, s.runner.ExtendPhase(d), "Phase extended")
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+
+	"project/internal/database"
+	"project/internal/runner"
+)
+
+// meanCI is the mean of a per-run value over the repetitions of a group with
+// its 95% confidence interval (Student-t, nil with fewer than two runs)
+type meanCI struct {
+	Mean     float64  `json:"mean"`
+	StdDev   float64  `json:"std_dev"` // Sample standard deviation across runs
+	CI95Low  *float64 `json:"ci95_low,omitempty"`
+	CI95High *float64 `json:"ci95_high,omitempty"`
+	RunCount int      `json:"run_count"`
+}
+
+// phaseAggregate aggregates the statistics of one phase across the runs of a group
+type phaseAggregate struct {
+	AveragePowerMW        meanCI `json:"average_power_mw"`
+	AverageThroughputMbps meanCI `json:"average_throughput_mbps"`
+}
+
+// groupAggregate aggregates the repetitions of a config
+type groupAggregate struct {
+	GroupID      string                    `json:"group_id"`
+	TestName     string                    `json:"test_name"`
+	TestIDs      []int64                   `json:"test_ids"`
+	Phases       map[string]phaseAggregate `json:"phases"`
+	DeltaPowerMW *meanCI                   `json:"delta_power_mw,omitempty"` // Load phase minus baseline
+}
+
+// tQuantile95 holds the two-sided 95% Student-t quantiles for 1 to 30 degrees of freedom
+var tQuantile95 = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+// newMeanCI calculates mean, sample standard deviation and 95% confidence interval
+func newMeanCI(values []float64) meanCI {
+	m := meanCI{RunCount: len(values)}
+	if len(values) == 0 {
+		return m
+	}
+	for _, v := range values {
+		m.Mean += v
+	}
+	m.Mean /= float64(len(values))
+	if len(values) < 2 {
+		return m
+	}
+
+	var variance float64
+	for _, v := range values {
+		variance += (v - m.Mean) * (v - m.Mean)
+	}
+	m.StdDev = math.Sqrt(variance / float64(len(values)-1))
+
+	t := 1.96
+	if df := len(values) - 1; df <= len(tQuantile95) {
+		t = tQuantile95[df-1]
+	}
+	halfWidth := t * m.StdDev / math.Sqrt(float64(len(values)))
+	low, high := m.Mean-halfWidth, m.Mean+halfWidth
+	m.CI95Low, m.CI95High = &low, &high
+	return m
+}
+
+// baselineStats returns the pre-test phase of a run, or the post-test phase if there is none
+func baselineStats(summary *database.TestSummary) (database.PhaseStats, bool) {
+	if stats, ok := summary.PhaseStats[string(runner.PhasePreTest)]; ok {
+		return stats, true
+	}
+	stats, ok := summary.PhaseStats[string(runner.PhasePostTest)]
+	return stats, ok
+}
+
+// groupAggregate aggregates the saved repetitions of a group. Phases are only
+// aggregated over the runs that have them.
+func (s *Server) groupAggregate(groupID string) (*groupAggregate, error) {
+	records, err := s.db.ListTestsByGroup(groupID)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("group %s not found", groupID)
+	}
+
+	agg := &groupAggregate{
+		GroupID: groupID,
+		Phases:  make(map[string]phaseAggregate),
+	}
+	power := make(map[string][]float64)
+	throughput := make(map[string][]float64)
+	var deltas []float64
+	for _, record := range records {
+		if record.Status != database.TestCompleted {
+			continue // Partial runs would distort the aggregate
+		}
+		var summary database.TestSummary
+		if err := json.Unmarshal([]byte(record.Summary), &summary); err != nil {
+			return nil, fmt.Errorf("failed to parse summary of test %d: %w", record.ID, err)
+		}
+		agg.TestIDs = append(agg.TestIDs, record.ID)
+
+		for phase, stats := range summary.PhaseStats {
+			power[phase] = append(power[phase], stats.AveragePowerMW)
+			throughput[phase] = append(throughput[phase], stats.AverageThroughputMbps)
+		}
+
+		load, hasLoad := summary.PhaseStats[string(runner.PhaseLoad)]
+		if baseline, ok := baselineStats(&summary); ok && hasLoad {
+			deltas = append(deltas, load.AveragePowerMW-baseline.AveragePowerMW)
+		}
+	}
+
+	// Strip the repetition suffix added by runner.ExpandRepetitions
+	agg.TestName = records[0].TestName
+	if i := strings.LastIndex(agg.TestName, " (run "); i >= 0 {
+		agg.TestName = agg.TestName[:i]
+	}
+
+	for phase := range power {
+		agg.Phases[phase] = phaseAggregate{
+			AveragePowerMW:        newMeanCI(power[phase]),
+			AverageThroughputMbps: newMeanCI(throughput[phase]),
+		}
+	}
+	if len(deltas) > 0 {
+		delta := newMeanCI(deltas)
+		agg.DeltaPowerMW = &delta
+	}
+	return agg, nil
+}
+
+// logGroupAggregate prints the power statistics of a group
+func logGroupAggregate(agg *groupAggregate) {
+	format := func(m meanCI) string {
+		if m.CI95Low == nil {
+			return fmt.Sprintf("%.1f (n=%d)", m.Mean, m.RunCount)
+		}
+		return fmt.Sprintf("%.1f [%.1f, %.1f] (n=%d)", m.Mean, *m.CI95Low, *m.CI95High, m.RunCount)
+	}
+
+	phases := make([]string, 0, len(agg.Phases))
+	for phase := range agg.Phases {
+		phases = append(phases, phase)
+	}
+	sort.Strings(phases)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Group %s (%s), %d runs:", agg.GroupID, agg.TestName, len(agg.TestIDs))
+	for _, phase := range phases {
+		fmt.Fprintf(&b, "\n  %s: power %s mW, throughput %s Mbps", phase,
+			format(agg.Phases[phase].AveragePowerMW), format(agg.Phases[phase].AverageThroughputMbps))
+	}
+	if agg.DeltaPowerMW != nil {
+		fmt.Fprintf(&b, "\n  load - baseline: %s mW", format(*agg.DeltaPowerMW))
+	}
+	log.Print(b.String())
+}
+
+// handleGetGroup returns the aggregate across the repetitions of a group
+func (s *Server) handleGetGroup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.db == nil {
+		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	groupID := strings.TrimPrefix(r.URL.Path, "/groups/")
+	if groupID == "" {
+		http.Error(w, "Missing group ID", http.StatusBadRequest)
+		return
+	}
+
+	agg, err := s.groupAggregate(groupID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(agg)
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"project/internal/database"
+)
+
+func TestNewMeanCI(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		mean   float64
+		stdDev float64
+		halfCI float64 // 0 = no interval
+	}{
+		{"no runs", nil, 0, 0, 0},
+		{"one run", []float64{5}, 5, 0, 0},
+		{"two runs", []float64{10, 12}, 11, math.Sqrt2, 12.706},
+		{"three runs", []float64{1, 2, 3}, 2, 1, 4.303 / math.Sqrt(3)},
+		// Beyond 30 degrees of freedom the normal quantile is used
+		{"40 runs", alternating(40), 0.5, math.Sqrt(10.0 / 39), 1.96 * math.Sqrt(10.0/39) / math.Sqrt(40)},
+	}
+	for _, tt := range tests {
+		m := newMeanCI(tt.values)
+		if m.RunCount != len(tt.values) || math.Abs(m.Mean-tt.mean) > 1e-9 || math.Abs(m.StdDev-tt.stdDev) > 1e-9 {
+			t.Errorf("%s: got %+v, want mean %v, std dev %v", tt.name, m, tt.mean, tt.stdDev)
+		}
+		if tt.halfCI == 0 {
+			if m.CI95Low != nil || m.CI95High != nil {
+				t.Errorf("%s: interval with fewer than two runs", tt.name)
+			}
+			continue
+		}
+		if m.CI95Low == nil || m.CI95High == nil {
+			t.Errorf("%s: no interval", tt.name)
+			continue
+		}
+		if math.Abs(*m.CI95Low-(tt.mean-tt.halfCI)) > 1e-9 || math.Abs(*m.CI95High-(tt.mean+tt.halfCI)) > 1e-9 {
+			t.Errorf("%s: interval [%v, %v], want %v ± %v", tt.name, *m.CI95Low, *m.CI95High, tt.mean, tt.halfCI)
+		}
+	}
+}
+
+// alternating returns n values alternating between 0 and 1
+func alternating(n int) []float64 {
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = float64(i % 2)
+	}
+	return values
+}
+
+func TestGroupAggregate(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "tests.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	runs := []struct {
+		status string
+		phases map[string]float64 // Average power by phase
+	}{
+		{database.TestCompleted, map[string]float64{"pre": 5000, "load": 8000}},
+		{database.TestCompleted, map[string]float64{"pre": 5200, "load": 8400}},
+		{database.TestAborted, map[string]float64{"pre": 9999, "load": 99999}},   // Left out
+		{database.TestCompleted, map[string]float64{"load": 8600, "post": 5100}}, // Post phase as baseline
+	}
+	for i, run := range runs {
+		summary := database.TestSummary{PhaseStats: make(map[string]database.PhaseStats)}
+		for phase, power := range run.phases {
+			summary.PhaseStats[phase] = database.PhaseStats{AveragePowerMW: power, AverageThroughputMbps: 900}
+		}
+		data, err := json.Marshal(summary)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.SaveTest(&database.TestRecord{
+			TestName: fmt.Sprintf("WAN load (run %d of %d)", i+1, len(runs)),
+			Summary:  string(data),
+			GroupID:  "g1",
+			Status:   run.status,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s := &Server{db: db}
+	agg, err := s.groupAggregate("g1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if agg.TestName != "WAN load" || len(agg.TestIDs) != 3 {
+		t.Errorf("test name %q, test IDs %v, want the 3 completed runs", agg.TestName, agg.TestIDs)
+	}
+
+	load := agg.Phases["load"].AveragePowerMW
+	if load.RunCount != 3 || math.Abs(load.Mean-25000.0/3) > 1e-9 {
+		t.Errorf("load power %+v, want mean 8333.3 over 3 runs", load)
+	}
+	if pre := agg.Phases["pre"].AveragePowerMW; pre.RunCount != 2 || pre.Mean != 5100 {
+		t.Errorf("pre power %+v, want mean 5100 over 2 runs", pre)
+	}
+
+	// Load minus baseline per run: 3000, 3200, 3500
+	if agg.DeltaPowerMW == nil {
+		t.Fatal("no delta")
+	}
+	want := newMeanCI([]float64{3000, 3200, 3500})
+	if d := agg.DeltaPowerMW; d.RunCount != 3 || math.Abs(d.Mean-want.Mean) > 1e-9 || math.Abs(*d.CI95Low-*want.CI95Low) > 1e-9 {
+		t.Errorf("delta %+v, want %+v", *d, want)
+	}
+
+	if _, err := s.groupAggregate("missing"); err == nil {
+		t.Error("unknown group aggregated")
+	}
+}
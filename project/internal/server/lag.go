@@ -0,0 +1,230 @@
+package server
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"project/internal/runner"
+)
+
+const (
+	// maxMeterLag is the largest meter delay considered by the estimation
+	maxMeterLag = 30 * time.Second
+	// minLagSteps is the number of candidate lags up to maxMeterLag needed for an
+	// estimate. Without throughput samples the candidates are poll intervals, so
+	// polls above maxMeterLag / minLagSteps cannot resolve the lag.
+	minLagSteps = 3
+	// minLagCorrelation is the correlation required to accept a lag estimate
+	minLagCorrelation = 0.5
+)
+
+// lagEstimate is the estimated delay of the power reading behind the load
+type lagEstimate struct {
+	Seconds     float64
+	Correlation float64 // Mean correlation at the estimated lag
+	Events      int     // Number of load changes used
+}
+
+// Lag returns the estimated delay as a duration
+func (l lagEstimate) Lag() time.Duration {
+	return time.Duration(l.Seconds * float64(time.Second))
+}
+
+// throughputPoint is the mean throughput over an interval, at its middle
+type throughputPoint struct {
+	Timestamp time.Time
+	Mbps      float64
+}
+
+// throughputTimeline is the throughput of a test at the finest resolution
+// available: the throughput samples while they were taken, the data points'
+// throughput before and after
+type throughputTimeline []throughputPoint
+
+// newThroughputTimeline merges the data points, whose throughput is the mean over
+// the preceding poll interval, and the samples, the mean over the preceding
+// throughput interval
+func newThroughputTimeline(points []runner.DataPoint, interval time.Duration, samples []runner.ThroughputSample, throughputInterval time.Duration) throughputTimeline {
+	var tl throughputTimeline
+	var first, last time.Time
+	if len(samples) > 0 {
+		first, last = samples[0].Timestamp.Add(-throughputInterval), samples[len(samples)-1].Timestamp.Add(throughputInterval)
+		for _, s := range samples {
+			tl = append(tl, throughputPoint{s.Timestamp.Add(-throughputInterval / 2), s.ThroughputMbps})
+		}
+	}
+	for _, dp := range points {
+		if len(samples) > 0 && !dp.Timestamp.Before(first) && !dp.Timestamp.After(last) {
+			continue
+		}
+		tl = append(tl, throughputPoint{dp.Timestamp.Add(-interval / 2), dp.ThroughputMbps})
+	}
+	sort.Slice(tl, func(i, j int) bool { return tl[i].Timestamp.Before(tl[j].Timestamp) })
+	return tl
+}
+
+// at returns the throughput of the point nearest to t
+func (tl throughputTimeline) at(t time.Time) float64 {
+	i := sort.Search(len(tl), func(i int) bool { return !tl[i].Timestamp.Before(t) })
+	if i == len(tl) {
+		return tl[len(tl)-1].Mbps
+	}
+	if i > 0 && t.Sub(tl[i-1].Timestamp) < tl[i].Timestamp.Sub(t) {
+		return tl[i-1].Mbps
+	}
+	return tl[i].Mbps
+}
+
+// estimateMeterLag cross-correlates the power readings with the throughput in a
+// window around every interface start and ramp step. Candidate lags are spaced
+// by the throughput interval if throughput samples were taken, by the poll
+// interval otherwise; the estimate is not ok if that spacing is too coarse for
+// maxMeterLag. The correlations are averaged over all events and the best lag is
+// refined by parabolic interpolation, or is the middle of a plateau of equally
+// good lags.
+func estimateMeterLag(result *runner.TestResult) (lagEstimate, bool) {
+	points := result.DataPoints
+	interval := result.Config.Interval
+	if interval <= 0 || len(points) < 3 {
+		return lagEstimate{}, false
+	}
+
+	step := interval
+	samples := result.ThroughputSamples
+	if ti := result.Config.ThroughputInterval; len(samples) > 0 && ti > 0 && ti < interval {
+		step = ti
+	} else {
+		samples = nil
+	}
+	maxStep := int(maxMeterLag / step)
+	if maxStep < minLagSteps {
+		return lagEstimate{}, false
+	}
+	tl := newThroughputTimeline(points, interval, samples, step)
+
+	// Times of the load changes
+	var events []time.Time
+	for _, dp := range points {
+		for _, evt := range dp.Events {
+			if evt.Type == runner.EventInterfaceStart || evt.Type == runner.EventRampStep {
+				events = append(events, evt.Timestamp)
+			}
+		}
+	}
+
+	halfWindow := maxMeterLag + 2*interval
+	meanCorr := make([]float64, maxStep+1)
+	used := 0
+	for _, at := range events {
+		lo := sort.Search(len(points), func(i int) bool { return !points[i].Timestamp.Before(at.Add(-halfWindow)) })
+		hi := sort.Search(len(points), func(i int) bool { return points[i].Timestamp.After(at.Add(halfWindow)) })
+
+		corr := make([]float64, maxStep+1)
+		ok := true
+		for k := range corr {
+			if corr[k], ok = shiftedCorrelation(points[lo:hi], tl, time.Duration(k)*step); !ok {
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		for k, c := range corr {
+			meanCorr[k] += c
+		}
+		used++
+	}
+	if used == 0 {
+		return lagEstimate{}, false
+	}
+
+	best := 0
+	for k := range meanCorr {
+		meanCorr[k] /= float64(used)
+		if meanCorr[k] > meanCorr[best] {
+			best = k
+		}
+	}
+	if meanCorr[best] < minLagCorrelation {
+		return lagEstimate{}, false
+	}
+
+	// A coarse poll leaves runs of lags that pair every reading with the same throughput
+	last := best
+	for last+1 < len(meanCorr) && meanCorr[best]-meanCorr[last+1] < 1e-9 {
+		last++
+	}
+	lag := float64(best+last) / 2
+	if last == best && best > 0 && best < maxStep {
+		a, b, c := meanCorr[best-1], meanCorr[best], meanCorr[best+1]
+		if denom := a - 2*b + c; denom < 0 {
+			lag += 0.5 * (a - c) / denom
+		}
+	}
+
+	return lagEstimate{
+		Seconds:     lag * step.Seconds(),
+		Correlation: meanCorr[best],
+		Events:      used,
+	}, true
+}
+
+// shiftedCorrelation returns the Pearson correlation between the power of the
+// data points and the throughput lag earlier. Invalid power readings are
+// skipped; the result is not ok if either series is constant.
+func shiftedCorrelation(points []runner.DataPoint, tl throughputTimeline, lag time.Duration) (float64, bool) {
+	var n, sumX, sumY, sumXX, sumYY, sumXY float64
+	for _, p := range points {
+		if !p.Valid() {
+			continue
+		}
+		x, y := tl.at(p.Timestamp.Add(-lag)), p.PowerMW
+		n++
+		sumX += x
+		sumY += y
+		sumXX += x * x
+		sumYY += y * y
+		sumXY += x * y
+	}
+	if n < 3 {
+		return 0, false
+	}
+
+	cov := sumXY - sumX*sumY/n
+	varX := sumXX - sumX*sumX/n
+	varY := sumYY - sumY*sumY/n
+	if varX <= 0 || varY <= 0 {
+		return 0, false
+	}
+	return cov / math.Sqrt(varX*varY), true
+}
+
+// lagCorrectedPhasePower averages the power per phase after moving the power
+// series back by lag, so each reading counts for the phase of the load it reflects
+func lagCorrectedPhasePower(points []runner.DataPoint, lag time.Duration) map[runner.Phase]float64 {
+	sums := make(map[runner.Phase]float64)
+	counts := make(map[runner.Phase]int)
+	for _, p := range points {
+		if !p.FreshPower() {
+			continue
+		}
+		// Phase of the data point nearest to the time the load was applied
+		loadTime := p.Timestamp.Add(-lag)
+		i := sort.Search(len(points), func(i int) bool { return !points[i].Timestamp.Before(loadTime) })
+		if i == len(points) || (i > 0 && loadTime.Sub(points[i-1].Timestamp) < points[i].Timestamp.Sub(loadTime)) {
+			i--
+		}
+		if i == 0 && len(points) > 1 && points[0].Timestamp.Sub(loadTime) > points[1].Timestamp.Sub(points[0].Timestamp)/2 {
+			continue // Load applied before the first data point
+		}
+		sums[points[i].Phase] += p.PowerMW
+		counts[points[i].Phase]++
+	}
+
+	avg := make(map[runner.Phase]float64, len(sums))
+	for phase, sum := range sums {
+		avg[phase] = sum / float64(counts[phase])
+	}
+	return avg
+}
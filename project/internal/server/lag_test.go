@@ -0,0 +1,213 @@
+package server
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"project/internal/runner"
+)
+
+var lagTestStart = time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+
+// lagTestLoad is a load test with ramp steps every 37 s, so the steps fall at
+// every offset within the poll interval
+type lagTestLoad struct {
+	loadStart, loadEnd time.Duration
+}
+
+func (l lagTestLoad) at(offset time.Duration) float64 {
+	if offset < l.loadStart || offset >= l.loadEnd {
+		return 0
+	}
+	step := int((offset - l.loadStart) / (37 * time.Second))
+	return float64(100 + (step*7)%10*90)
+}
+
+// mean is the mean load over the interval ending at offset
+func (l lagTestLoad) mean(offset, interval time.Duration) float64 {
+	const resolution = 100 * time.Millisecond
+	var sum float64
+	n := 0
+	for u := offset - interval + resolution; u <= offset; u += resolution {
+		sum += l.at(u)
+		n++
+	}
+	return sum / float64(n)
+}
+
+// lagTestResult simulates a meter reading 5 W + 2 mW/Mbps of the load delay
+// earlier. Poll timestamps jitter by up to 50 ms like real ticks.
+func lagTestResult(interval, throughputInterval, delay time.Duration) *runner.TestResult {
+	load := lagTestLoad{loadStart: 3 * time.Minute, loadEnd: 63 * time.Minute}
+	end := load.loadEnd + 3*time.Minute
+	result := &runner.TestResult{
+		Config:    runner.TestConfig{Interval: interval, ThroughputInterval: throughputInterval},
+		StartTime: lagTestStart,
+	}
+
+	var events []runner.Event
+	for offset := load.loadStart; offset < load.loadEnd; offset += 37 * time.Second {
+		evt := runner.Event{Type: runner.EventRampStep, Timestamp: lagTestStart.Add(offset)}
+		if offset == load.loadStart {
+			evt.Type = runner.EventInterfaceStart
+		}
+		events = append(events, evt)
+	}
+
+	for i := 1; time.Duration(i)*interval <= end; i++ {
+		offset := time.Duration(i)*interval + time.Duration(i*37%101-50)*time.Millisecond
+		dp := runner.DataPoint{
+			Timestamp:      lagTestStart.Add(offset),
+			PowerMW:        5000 + 2*load.at(offset-delay),
+			ThroughputMbps: load.mean(offset, interval),
+			Quality:        runner.QualityOK,
+			Phase:          runner.PhaseLoad,
+		}
+		if offset < load.loadStart {
+			dp.Phase = runner.PhasePreTest
+		} else if offset >= load.loadEnd {
+			dp.Phase = runner.PhasePostTest
+		}
+		for len(events) > 0 && !events[0].Timestamp.After(dp.Timestamp) {
+			dp.Events = append(dp.Events, events[0])
+			events = events[1:]
+		}
+		result.DataPoints = append(result.DataPoints, dp)
+	}
+
+	if throughputInterval > 0 {
+		for offset := load.loadStart + throughputInterval; offset <= load.loadEnd; offset += throughputInterval {
+			result.ThroughputSamples = append(result.ThroughputSamples, runner.ThroughputSample{
+				Timestamp:      lagTestStart.Add(offset),
+				ThroughputMbps: load.mean(offset, throughputInterval),
+				Phase:          runner.PhaseLoad,
+			})
+		}
+	}
+	return result
+}
+
+func TestEstimateMeterLag(t *testing.T) {
+	tests := []struct {
+		name               string
+		interval           time.Duration
+		throughputInterval time.Duration
+		delay              time.Duration
+		tolerance          time.Duration // 0 = not estimable
+	}{
+		{"fast poll", time.Second, 0, 5 * time.Second, time.Second},
+		{"fast poll without lag", time.Second, 0, 0, time.Second},
+		{"fast poll with samples", 2 * time.Second, 500 * time.Millisecond, 9 * time.Second, time.Second},
+		{"coarse poll with samples", 15 * time.Second, time.Second, 7 * time.Second, time.Second},
+		{"60s poll with samples", time.Minute, time.Second, 12 * time.Second, time.Second},
+		{"coarse poll", 15 * time.Second, 0, 7 * time.Second, 0},
+		{"60s poll", time.Minute, 0, 12 * time.Second, 0},
+		{"60s poll, samples as slow as the poll", time.Minute, time.Minute, 12 * time.Second, 0},
+	}
+	for _, tt := range tests {
+		lag, ok := estimateMeterLag(lagTestResult(tt.interval, tt.throughputInterval, tt.delay))
+		if tt.tolerance == 0 {
+			if ok {
+				t.Errorf("%s: estimated %.1fs, want not estimable", tt.name, lag.Seconds)
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("%s: not estimable", tt.name)
+			continue
+		}
+		if math.Abs(lag.Seconds-tt.delay.Seconds()) > tt.tolerance.Seconds() {
+			t.Errorf("%s: estimated %.2fs, want %s ± %s", tt.name, lag.Seconds, tt.delay, tt.tolerance)
+		}
+		if lag.Correlation < 0.9 || lag.Events < 90 {
+			t.Errorf("%s: correlation %.2f over %d events", tt.name, lag.Correlation, lag.Events)
+		}
+	}
+}
+
+func TestEstimateMeterLagUncorrelated(t *testing.T) {
+	// Power that does not follow the load is not estimated as a lag
+	result := lagTestResult(time.Second, 0, 5*time.Second)
+	for i := range result.DataPoints {
+		result.DataPoints[i].PowerMW = 5000 + float64(i*7919%13)
+	}
+	if lag, ok := estimateMeterLag(result); ok {
+		t.Errorf("estimated %.1fs (correlation %.2f) for power unrelated to the load", lag.Seconds, lag.Correlation)
+	}
+}
+
+func TestShiftedCorrelation(t *testing.T) {
+	result := lagTestResult(time.Second, 0, 4*time.Second)
+	tl := newThroughputTimeline(result.DataPoints, time.Second, nil, 0)
+	window := result.DataPoints[170:260]
+
+	// The fit is best at the injected delay
+	best, bestCorr := time.Duration(-1), 0.0
+	for lag := time.Duration(0); lag <= 8*time.Second; lag += time.Second {
+		c, ok := shiftedCorrelation(window, tl, lag)
+		if !ok {
+			t.Fatalf("at %s: not ok", lag)
+		}
+		if c > bestCorr {
+			best, bestCorr = lag, c
+		}
+	}
+	if best != 4*time.Second || bestCorr < 0.999 {
+		t.Errorf("best fit at %s with correlation %.3f, want 4s", best, bestCorr)
+	}
+	if c, _ := shiftedCorrelation(window, tl, 0); c > 0.95 {
+		t.Errorf("without lag: correlation %.3f, want a worse fit", c)
+	}
+
+	// Invalid readings are skipped, a constant series has no correlation
+	invalid := append([]runner.DataPoint(nil), window...)
+	for i := range invalid {
+		if i%2 == 0 {
+			invalid[i].Quality = runner.QualityMissing
+			invalid[i].PowerMW = 0
+		}
+	}
+	if c, ok := shiftedCorrelation(invalid, tl, 4*time.Second); !ok || c < 0.999 {
+		t.Errorf("with missing readings: correlation %.3f, ok %v, want 1", c, ok)
+	}
+	if _, ok := shiftedCorrelation(result.DataPoints[:150], tl, 4*time.Second); ok {
+		t.Error("constant pre-test series correlated")
+	}
+}
+
+func TestLagCorrectedPhasePower(t *testing.T) {
+	// 10 W idle, 20 W under load from 10 s to 20 s, read 3 s late
+	const delay = 3 * time.Second
+	var points []runner.DataPoint
+	for i := 0; i < 30; i++ {
+		dp := runner.DataPoint{
+			Timestamp: lagTestStart.Add(time.Duration(i)*time.Second + time.Duration(i%3-1)*20*time.Millisecond),
+			PowerMW:   10000,
+			Quality:   runner.QualityOK,
+			Phase:     runner.PhaseLoad,
+		}
+		if i < 10 {
+			dp.Phase = runner.PhasePreTest
+		} else if i >= 20 {
+			dp.Phase = runner.PhasePostTest
+		}
+		if i-3 >= 10 && i-3 < 20 {
+			dp.PowerMW = 20000
+		}
+		points = append(points, dp)
+	}
+
+	avg := lagCorrectedPhasePower(points, delay)
+	want := map[runner.Phase]float64{runner.PhasePreTest: 10000, runner.PhaseLoad: 20000, runner.PhasePostTest: 10000}
+	for phase, w := range want {
+		if avg[phase] != w {
+			t.Errorf("%s: %v mW, want %v", phase, avg[phase], w)
+		}
+	}
+
+	// Without the correction the delayed readings spill into the neighbouring phases
+	if naive := lagCorrectedPhasePower(points, 0); naive[runner.PhaseLoad] != 17000 {
+		t.Errorf("uncorrected load: %v mW, want 17000", naive[runner.PhaseLoad])
+	}
+}
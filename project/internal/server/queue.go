@@ -0,0 +1,309 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"project/internal/database"
+	"project/internal/schedule"
+)
+
+// queuePollInterval is how often the queue worker looks for due entries
+const queuePollInterval = 5 * time.Second
+
+// runQueue drains the test queue, one entry at a time whenever no test is
+// running. Entries interrupted by a restart are run again from the start.
+func (s *Server) runQueue() {
+	if n, err := s.db.RequeueInterrupted(); err != nil {
+		log.Printf("Failed to requeue interrupted tests: %v", err)
+	} else if n > 0 {
+		log.Printf("Requeued %d interrupted queued test(s)", n)
+	}
+
+	ticker := time.NewTicker(queuePollInterval)
+	defer ticker.Stop()
+	for {
+		s.runNextQueued()
+		select {
+		case <-ticker.C:
+		case <-s.queueWake:
+		}
+	}
+}
+
+// wakeQueue makes the worker check the queue without waiting for the next poll
+func (s *Server) wakeQueue() {
+	select {
+	case s.queueWake <- struct{}{}:
+	default:
+	}
+}
+
+// runNextQueued runs the first due queue entry, if any, and blocks until it has finished
+func (s *Server) runNextQueued() {
+	entries, err := s.db.ListQueue()
+	if err != nil {
+		log.Printf("Failed to read test queue: %v", err)
+		return
+	}
+
+	now := time.Now()
+	var entry *database.QueueEntry
+	for _, e := range entries {
+		if e.Status == database.QueueQueued && (e.StartAt == nil || !e.StartAt.After(now)) {
+			entry = e
+			break
+		}
+	}
+	if entry == nil {
+		return
+	}
+
+	form, err := url.ParseQuery(entry.Form)
+	var job *testJob
+	if err == nil {
+		job, err = s.parseTestJob(form)
+	}
+	if err != nil {
+		entry.Status = database.QueueFailed
+		entry.Error = err.Error()
+		entry.FinishedAt = &now
+		s.saveQueueEntry(entry)
+		return
+	}
+
+	ctx, ok := s.claimTest()
+	if !ok {
+		return // A manually started test is running, retry on the next poll
+	}
+	s.mu.Lock()
+	s.queueID = entry.ID
+	s.mu.Unlock()
+
+	log.Printf("Starting queued test %d (%s)", entry.ID, entry.TestName)
+	entry.Status = database.QueueRunning
+	entry.Error = ""
+	entry.StartedAt = &now
+	entry.FinishedAt = nil
+	entry.RunCount++
+	s.saveQueueEntry(entry)
+
+	err = s.runJob(ctx, job)
+
+	s.mu.Lock()
+	s.queueID = 0
+	s.mu.Unlock()
+
+	// The entry may have been cancelled through the API while it was running
+	current, getErr := s.db.GetQueueEntry(entry.ID)
+	if getErr == nil && current.Status == database.QueueCancelled {
+		log.Printf("Queued test %d cancelled", entry.ID)
+		return
+	}
+
+	finished := time.Now()
+	entry.FinishedAt = &finished
+	entry.Status = database.QueueDone
+	if err != nil {
+		entry.Status = database.QueueFailed
+		entry.Error = err.Error()
+	}
+
+	// Recurring entries go back into the queue for their next start time
+	if entry.Cron != "" {
+		if cron, cronErr := schedule.ParseCron(entry.Cron); cronErr == nil {
+			if next := cron.Next(finished); next.IsZero() {
+				log.Printf("Queued test %d has no further start time for %q", entry.ID, entry.Cron)
+			} else {
+				entry.StartAt = &next
+				entry.Status = database.QueueQueued
+				log.Printf("Queued test %d rescheduled for %s", entry.ID, next.Format(time.RFC3339))
+			}
+		}
+	}
+	s.saveQueueEntry(entry)
+}
+
+func (s *Server) saveQueueEntry(entry *database.QueueEntry) {
+	if err := s.db.UpdateQueueEntry(entry); err != nil {
+		log.Printf("Failed to update queued test %d: %v", entry.ID, err)
+	}
+}
+
+// parseStartAt reads an RFC 3339 time or a datetime-local value in server time
+func parseStartAt(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02T15:04:05"} {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid start time %q", value)
+}
+
+// handleQueue lists the test queue (GET) or enqueues a test (POST). Enqueueing
+// takes the same form as /start plus an optional start_at time and cron schedule.
+func (s *Server) handleQueue(w http.ResponseWriter, r *http.Request) {
+	if s.db == nil {
+		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		entries, err := s.db.ListQueue()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entries)
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if err := r.ParseMultipartForm(32 << 20); err != nil && err != http.ErrNotMultipart {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, err := s.parseTestJob(r.Form); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entry := &database.QueueEntry{
+		TestName: r.Form.Get("test_name"),
+		Cron:     strings.TrimSpace(r.Form.Get("cron")),
+	}
+	if entry.TestName == "" {
+		entry.TestName = "Unnamed Test"
+	}
+	if startAt := r.Form.Get("start_at"); startAt != "" {
+		t, err := parseStartAt(startAt)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		entry.StartAt = &t
+	}
+	if entry.Cron != "" {
+		cron, err := schedule.ParseCron(entry.Cron)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if entry.StartAt == nil {
+			next := cron.Next(time.Now())
+			if next.IsZero() {
+				http.Error(w, fmt.Sprintf("cron expression %q has no upcoming start time", entry.Cron), http.StatusBadRequest)
+				return
+			}
+			entry.StartAt = &next
+		}
+	}
+
+	// The schedule is kept in its own columns, not in the stored form
+	form := url.Values{}
+	for key, values := range r.Form {
+		if key != "start_at" && key != "cron" {
+			form[key] = values
+		}
+	}
+	entry.Form = form.Encode()
+
+	id, err := s.db.EnqueueTest(entry)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.wakeQueue()
+
+	saved, err := s.db.GetQueueEntry(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+// handleMoveQueueEntry moves a queued test to the 1-based position given by
+// the position form value
+func (s *Server) handleMoveQueueEntry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.db == nil {
+		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/queue/move/"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid queue entry ID", http.StatusBadRequest)
+		return
+	}
+	position, err := strconv.Atoi(r.FormValue("position"))
+	if err != nil {
+		http.Error(w, "Invalid position", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.db.MoveQueueEntry(id, position); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	s.wakeQueue()
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Queue entry moved"))
+}
+
+// handleCancelQueueEntry cancels a queued test, stopping it if it is running
+func (s *Server) handleCancelQueueEntry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodDelete && r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.db == nil {
+		http.Error(w, "Database not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/queue/cancel/"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid queue entry ID", http.StatusBadRequest)
+		return
+	}
+
+	cancelled, err := s.db.CancelQueueEntry(id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !cancelled {
+		http.Error(w, "Queue entry not found or already finished", http.StatusNotFound)
+		return
+	}
+
+	s.mu.Lock()
+	if s.queueID == id && s.cancel != nil {
+		s.cancel() // runJob releases the runner once the test has stopped
+	}
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Queue entry cancelled"))
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"project/internal/database"
+	"project/internal/runner"
+)
+
+const (
+	// heartbeatInterval is how often a recording marks its test as still running
+	heartbeatInterval = 10 * time.Second
+	// abandonedAfter is how long a running test may go without a heartbeat
+	// before it is considered abandoned by a crashed process
+	abandonedAfter = 3 * heartbeatInterval
+)
+
+// testRecorder streams running tests into the database
+type testRecorder struct {
+	db *database.Database
+}
+
+// NewTestRecorder returns a recorder streaming tests into db
+func NewTestRecorder(db *database.Database) runner.Recorder {
+	return testRecorder{db: db}
+}
+
+// testRecording is a test being streamed into the database
+type testRecording struct {
+	db            *database.Database
+	id            int64
+	seq           int
+	throughputSeq int
+	stop          chan struct{}
+}
+
+func (t testRecorder) BeginRecording(result *runner.TestResult) (runner.Recording, error) {
+	configJSON, err := json.Marshal(result.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	id, err := t.db.BeginTest(&database.TestRecord{
+		TestName:   result.Config.TestName,
+		DeviceName: result.Config.DeviceName,
+		Timestamp:  result.StartTime,
+		Config:     string(configJSON),
+		CampaignID: result.Config.CampaignID,
+		GroupID:    result.Config.GroupID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Recording test %d", id)
+	rec := &testRecording{db: t.db, id: id, stop: make(chan struct{})}
+	go rec.heartbeat()
+	return rec, nil
+}
+
+// heartbeat keeps the test from being recovered as crashed while this process
+// is recording it
+func (t *testRecording) heartbeat() {
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := t.db.Heartbeat(t.id); err != nil {
+				log.Printf("Failed to update heartbeat of test %d: %v", t.id, err)
+			}
+		case <-t.stop:
+			return
+		}
+	}
+}
+
+func (t *testRecording) AddDataPoint(dp runner.DataPoint) error {
+	data, err := json.Marshal(dp)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data point: %w", err)
+	}
+	t.seq++
+	return t.db.AppendDataPoint(t.id, t.seq, string(data))
+}
+
+func (t *testRecording) AddThroughputSamples(samples []runner.ThroughputSample) error {
+	rows := make([]string, len(samples))
+	for i, sample := range samples {
+		data, err := json.Marshal(sample)
+		if err != nil {
+			return fmt.Errorf("failed to marshal throughput sample: %w", err)
+		}
+		rows[i] = string(data)
+	}
+	if err := t.db.AppendThroughputSamples(t.id, t.throughputSeq+1, rows); err != nil {
+		return err
+	}
+	t.throughputSeq += len(samples)
+	return nil
+}
+
+// Finish saves the complete result. A test that returned an error or was
+// stopped by a safety rule is saved as aborted.
+func (t *testRecording) Finish(result *runner.TestResult, testErr error) error {
+	close(t.stop)
+
+	record, err := newTestRecord(result)
+	if err != nil {
+		return err
+	}
+	record.ID = t.id
+	setTestError(record, testErr)
+
+	if err := t.db.FinishTest(record); err != nil {
+		return err
+	}
+	log.Printf("Test %d saved as %s", t.id, record.Status)
+	return nil
+}
+
+// setTestError marks the record of a test that returned testErr as aborted
+func setTestError(record *database.TestRecord, testErr error) {
+	if testErr == nil {
+		return
+	}
+	record.Status = database.TestAborted
+	record.Error = testErr.Error()
+	if errors.Is(testErr, context.Canceled) {
+		record.Error = "stopped"
+	}
+}
+
+// watchAbandonedTests recovers abandoned tests at startup and then
+// periodically, so a test whose process crashed while this server runs is
+// finalized as well
+func (s *Server) watchAbandonedTests() {
+	for {
+		s.recoverTests(time.Now().Add(-abandonedAfter))
+		time.Sleep(abandonedAfter)
+	}
+}
+
+// recoverTests finalizes the running tests without a heartbeat since
+// staleBefore, left by a crash or restart, so their data points can be
+// analyzed like those of an aborted test. Tests still recorded by a live
+// process, such as a headless run, keep their heartbeat fresh and are skipped.
+func (s *Server) recoverTests(staleBefore time.Time) {
+	records, err := s.db.ListAbandonedTests(staleBefore)
+	if err != nil {
+		log.Printf("Failed to read interrupted tests: %v", err)
+		return
+	}
+	for _, record := range records {
+		if err := s.finalizeCrashedTest(record); err != nil {
+			log.Printf("Failed to finalize interrupted test %d: %v", record.ID, err)
+			continue
+		}
+		log.Printf("Finalized interrupted test %d (%s) as crashed", record.ID, record.TestName)
+	}
+}
+
+// finalizeCrashedTest computes the summary of a test's streamed data points
+// and saves it as crashed. The test ends with its last data point.
+func (s *Server) finalizeCrashedTest(record *database.TestRecord) error {
+	result := &runner.TestResult{
+		StartTime: record.Timestamp,
+		EndTime:   record.Timestamp,
+	}
+	if err := json.Unmarshal([]byte(record.Config), &result.Config); err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+	if err := json.Unmarshal([]byte(record.Data), &result.DataPoints); err != nil {
+		return fmt.Errorf("failed to parse data points: %w", err)
+	}
+	if record.Throughput != "" {
+		if err := json.Unmarshal([]byte(record.Throughput), &result.ThroughputSamples); err != nil {
+			return fmt.Errorf("failed to parse throughput samples: %w", err)
+		}
+	}
+	if n := len(result.DataPoints); n > 0 {
+		result.EndTime = result.DataPoints[n-1].Timestamp
+	}
+
+	summaryJSON, err := json.Marshal(CalculateTestSummary(result))
+	if err != nil {
+		return fmt.Errorf("failed to marshal summary: %w", err)
+	}
+	record.Summary = string(summaryJSON)
+	record.Status = database.TestCrashed
+	record.Error = "stopped while the test was running"
+	return s.db.FinishTest(record)
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"project/internal/database"
+	"project/internal/runner"
+)
+
+func TestRecoverTestsSkipsLiveRecordings(t *testing.T) {
+	db, err := database.New(filepath.Join(t.TempDir(), "tests.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	// A test recorded by a live process, such as a headless run -save
+	result := &runner.TestResult{
+		Config:    runner.TestConfig{TestName: "WAN load", Interval: time.Second},
+		StartTime: lagTestStart,
+	}
+	rec, err := NewTestRecorder(db).BeginRecording(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.AddDataPoint(summaryPoint(time.Second, runner.PhaseLoad, 9000, runner.QualityOK)); err != nil {
+		t.Fatal(err)
+	}
+	id := rec.(*testRecording).id
+
+	s := &Server{db: db}
+	s.recoverTests(time.Now().Add(-abandonedAfter))
+	if record, err := db.GetTest(id); err != nil || record.Status != database.TestRunning {
+		t.Fatalf("live test: %+v, %v, want still running", record, err)
+	}
+
+	// Once the heartbeat is stale, the test is finalized from its data points
+	s.recoverTests(time.Now().Add(time.Minute))
+	record, err := db.GetTest(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Status != database.TestCrashed || record.Summary == "" {
+		t.Errorf("abandoned test: status %q, summary %q, want crashed with a summary", record.Status, record.Summary)
+	}
+
+	// A finished test is not touched by its heartbeat or by the recovery
+	rec2, err := NewTestRecorder(db).BeginRecording(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rec2.Finish(result, nil); err != nil {
+		t.Fatal(err)
+	}
+	id2 := rec2.(*testRecording).id
+	if err := db.Heartbeat(id2); err != nil {
+		t.Fatal(err)
+	}
+	s.recoverTests(time.Now().Add(time.Minute))
+	if record, err := db.GetTest(id2); err != nil || record.Status != database.TestCompleted {
+		t.Errorf("finished test: %+v, %v, want completed", record, err)
+	}
+}
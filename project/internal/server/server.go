@@ -8,6 +8,7 @@ import (
 	"log"
 	"math"
 	"net/http"
+	"net/url"
 	"strconv"
 	"sync"
 	"time"
@@ -25,15 +26,40 @@ type Server struct {
 	discovery *network.Discovery
 	mu        sync.Mutex
 	cancel    context.CancelFunc
+	queueID   int64         // Queue entry of the running test, 0 if started by hand
+	queueWake chan struct{} // Signals the queue worker to check for due entries
+
+	hookCommands bool // Tests started through the web UI or API may run local hook commands
 }
 
 func NewServer(r *runner.Runner, db *database.Database) *Server {
-	return &Server{
+	if db != nil {
+		r.SetRecorder(NewTestRecorder(db))
+	}
+	s := &Server{
 		runner:    r,
 		db:        db,
 		broker:    NewBroker(),
 		discovery: network.NewDiscovery(),
+		queueWake: make(chan struct{}, 1),
 	}
+
+	// Throughput samples are sent as their own event type, data points as plain messages
+	throughputChan := make(chan runner.ThroughputSample, 16)
+	r.SetThroughputUpdates(throughputChan)
+	go func() {
+		for sample := range throughputChan {
+			data, _ := json.Marshal(sample)
+			s.broker.Broadcast([]byte(fmt.Sprintf("event: throughput\ndata: %s\n\n", data)))
+		}
+	}()
+	return s
+}
+
+// AllowHookCommands lets tests started through the web UI or API run local
+// hook commands. Without it only HTTP hooks are accepted.
+func (s *Server) AllowHookCommands() {
+	s.hookCommands = true
 }
 
 func (s *Server) Start(addr string) error {
@@ -43,6 +69,11 @@ func (s *Server) Start(addr string) error {
 	http.HandleFunc("/start", s.handleStart)
 	http.HandleFunc("/stop", s.handleStop)
 	http.HandleFunc("/marker", s.handleAddMarker)
+	http.HandleFunc("/control", s.handleControlState)
+	http.HandleFunc("/control/throughput", s.handleSetThroughput)
+	http.HandleFunc("/control/pause", s.handlePauseLoad)
+	http.HandleFunc("/control/resume", s.handleResumeLoad)
+	http.HandleFunc("/control/extend", s.handleExtendPhase)
 	http.HandleFunc("/test-fritzbox", s.handleTestFritzbox)
 	http.HandleFunc("/test-target", s.handleTestTarget)
 	http.HandleFunc("/interfaces", s.handleGetInterfaces)
@@ -52,12 +83,24 @@ func (s *Server) Start(addr string) error {
 	http.HandleFunc("/tests", s.handleListTests)
 	http.HandleFunc("/tests/", s.handleGetTest)
 	http.HandleFunc("/tests/delete/", s.handleDeleteTest)
+	http.HandleFunc("/campaigns/", s.handleGetCampaign)
+	http.HandleFunc("/groups/", s.handleGetGroup)
+
+	// Test queue endpoints
+	http.HandleFunc("/queue", s.handleQueue)
+	http.HandleFunc("/queue/move/", s.handleMoveQueueEntry)
+	http.HandleFunc("/queue/cancel/", s.handleCancelQueueEntry)
 
 	// Discovery endpoints
 	http.HandleFunc("/discover", s.handleDiscover)
 	http.HandleFunc("/discovered-devices", s.handleGetDiscoveredDevices)
 	http.HandleFunc("/pcap-devices", s.handleListPcapDevices)
 
+	if s.db != nil {
+		go s.watchAbandonedTests()
+		go s.runQueue()
+	}
+
 	log.Printf("Server listening on %s", addr)
 	return http.ListenAndServe(addr, nil)
 }
@@ -80,86 +123,130 @@ func (s *Server) handleAnalysis(w http.ResponseWriter, r *http.Request) {
 	tmpl.Execute(w, nil)
 }
 
+// testJob is a parsed test start request: a single test or a series of runs
+type testJob struct {
+	config runner.TestConfig
+	runs   []runner.MatrixRun // Matrix runs and repetitions, nil for a single test
+	gap    time.Duration      // Pause between the runs of a series
+}
+
 func (s *Server) handleStart(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	s.mu.Lock()
-	if s.cancel != nil {
-		s.mu.Unlock()
+	if err := r.ParseMultipartForm(32 << 20); err != nil && err != http.ErrNotMultipart {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	job, err := s.parseTestJob(r.Form)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx, ok := s.claimTest()
+	if !ok {
 		http.Error(w, "Test already running", http.StatusConflict)
 		return
 	}
+	go s.runJob(ctx, job)
 
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Test started"))
+}
+
+// claimTest reserves the runner for a new test. It fails if a test is running.
+func (s *Server) claimTest() (context.Context, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		return nil, false
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 	s.cancel = cancel
-	s.mu.Unlock()
+	return ctx, true
+}
 
-	// Parse form values
-	testName := r.FormValue("test_name")
+// parseTestJob builds the test configuration from the start form
+func (s *Server) parseTestJob(form url.Values) (*testJob, error) {
+	testName := form.Get("test_name")
 	if testName == "" {
 		testName = "Unnamed Test"
 	}
 
-	deviceName := r.FormValue("device_name")
+	deviceName := form.Get("device_name")
 	if deviceName == "" {
 		deviceName = "Unknown Device"
 	}
 
-	durationStr := r.FormValue("duration")
+	durationStr := form.Get("duration")
 	duration, _ := time.ParseDuration(durationStr)
 	if duration == 0 {
 		duration = 1 * time.Minute
 	}
 
-	pollIntervalStr := r.FormValue("poll_interval")
+	pollIntervalStr := form.Get("poll_interval")
 	pollInterval, _ := time.ParseDuration(pollIntervalStr)
 	if pollInterval == 0 {
 		pollInterval = 60 * time.Second
 	}
 
-	preTestStr := r.FormValue("pre_test_time")
+	preTestStr := form.Get("pre_test_time")
 	preTestTime, _ := time.ParseDuration(preTestStr)
 
-	postTestStr := r.FormValue("post_test_time")
+	postTestStr := form.Get("post_test_time")
 	postTestTime, _ := time.ParseDuration(postTestStr)
 
-	loadEnabled := r.FormValue("load_enabled") == "on"
-	targetIP := r.FormValue("target_ip")
+	readTimeout, _ := time.ParseDuration(form.Get("read_timeout"))
+
+	readRetries := 2
+	if v, err := strconv.Atoi(form.Get("read_retries")); err == nil && v >= 0 {
+		readRetries = v
+	}
+
+	retryBackoff, _ := time.ParseDuration(form.Get("retry_backoff"))
+
+	// Throughput sampling independent of the poll interval, 0 samples at every power reading
+	throughputInterval, _ := time.ParseDuration(form.Get("throughput_interval"))
+	if throughputInterval > 0 && throughputInterval < runner.MinThroughputInterval {
+		return nil, fmt.Errorf("throughput interval must be at least %s", runner.MinThroughputInterval)
+	}
+
+	loadEnabled := form.Get("load_enabled") == "on"
+	targetIP := form.Get("target_ip")
 	
-	targetPort, _ := strconv.Atoi(r.FormValue("target_port"))
+	targetPort, _ := strconv.Atoi(form.Get("target_port"))
 	if targetPort == 0 {
 		targetPort = 9 // Default discard
 	}
 
-	protocol := r.FormValue("protocol")
+	protocol := form.Get("protocol")
 	if protocol == "" {
 		protocol = "udp"
 	}
 
-	targetMAC := r.FormValue("target_mac")
+	targetMAC := form.Get("target_mac")
 
-	packetSize, _ := strconv.Atoi(r.FormValue("packet_size"))
+	packetSize, _ := strconv.Atoi(form.Get("packet_size"))
 	if packetSize == 0 {
 		packetSize = 1400
 	}
 
 	// Parse per-interface configurations
-	r.ParseForm()
-	interfaces := r.Form["interfaces"]
+	interfaces := form["interfaces"]
 	
 	var interfaceConfigs []loadgen.InterfaceConfig
 	for _, ifaceName := range interfaces {
-		workers, _ := strconv.Atoi(r.FormValue("workers_" + ifaceName))
+		workers, _ := strconv.Atoi(form.Get("workers_" + ifaceName))
 		if workers == 0 {
 			workers = 10 // Default: 10 workers for good balance
 		}
-		throughput, _ := strconv.ParseFloat(r.FormValue("throughput_" + ifaceName), 64)
-		rampSteps, _ := strconv.Atoi(r.FormValue("ramp_" + ifaceName))
-		preTime, _ := time.ParseDuration(r.FormValue("pretime_" + ifaceName))
-		rampDuration, _ := time.ParseDuration(r.FormValue("rampduration_" + ifaceName))
+		throughput, _ := strconv.ParseFloat(form.Get("throughput_" + ifaceName), 64)
+		rampSteps, _ := strconv.Atoi(form.Get("ramp_" + ifaceName))
+		preTime, _ := time.ParseDuration(form.Get("pretime_" + ifaceName))
+		rampDuration, _ := time.ParseDuration(form.Get("rampduration_" + ifaceName))
 
 		interfaceConfigs = append(interfaceConfigs, loadgen.InterfaceConfig{
 			Name:             ifaceName,
@@ -194,56 +281,233 @@ func (s *Server) handleStart(w http.ResponseWriter, r *http.Request) {
 	}
 
 	config := runner.TestConfig{
-		Duration:     duration,
-		Interval:     pollInterval,
-		PreTestTime:  preTestTime,
-		PostTestTime: postTestTime,
-		Description:  "Web UI Test",
-		TestName:     testName,
-		DeviceName:   deviceName,
-		LoadEnabled:  loadEnabled,
-		LoadConfig:   loadConfig,
+		Duration:           duration,
+		Interval:           pollInterval,
+		ThroughputInterval: throughputInterval,
+		PreTestTime:        preTestTime,
+		PostTestTime:       postTestTime,
+		Description:        "Web UI Test",
+		TestName:           testName,
+		DeviceName:         deviceName,
+		ReadTimeout:        readTimeout,
+		ReadRetries:        readRetries,
+		RetryBackoff:       retryBackoff,
+		LoadEnabled:        loadEnabled,
+		LoadConfig:         loadConfig,
 	}
 
-	go func() {
-		defer func() {
-			s.mu.Lock()
-			s.cancel = nil
-			s.mu.Unlock()
-			s.broker.Broadcast([]byte("event: done\ndata: Test finished\n\n"))
-		}()
-
-		updateChan := make(chan runner.DataPoint)
-		
-		// Forward updates to SSE broker
-		go func() {
-			for dp := range updateChan {
-				data, _ := json.Marshal(dp)
-				msg := fmt.Sprintf("data: %s\n\n", data)
-				s.broker.Broadcast([]byte(msg))
-			}
-		}()
+	// Power-cycle boot test instead of the load test
+	if form.Get("test_type") == "boot" {
+		if !s.runner.CanSwitch() {
+			return nil, fmt.Errorf("power meter cannot switch its outlet")
+		}
+
+		offTime, _ := time.ParseDuration(form.Get("boot_off_time"))
+		if offTime == 0 {
+			offTime = 10 * time.Second
+		}
+		bootTargetIP := form.Get("boot_target_ip")
+		if bootTargetIP == "" {
+			bootTargetIP = targetIP
+		}
+		bootTargetPort, _ := strconv.Atoi(form.Get("boot_target_port"))
+		if bootTargetPort == 0 {
+			bootTargetPort = 80 // Web interface
+		}
+		bootTimeout, _ := time.ParseDuration(form.Get("boot_timeout"))
+		postReadyTime, _ := time.ParseDuration(form.Get("boot_post_time"))
+
+		// Sample as fast as the meter allows unless an interval is given
+		bootInterval, _ := time.ParseDuration(form.Get("boot_poll_interval"))
+
+		config.Description = "Web UI Boot Test"
+		config.Interval = bootInterval
+		config.LoadEnabled = false
+		config.Boot = &runner.BootConfig{
+			OffTime:       offTime,
+			TargetIP:      bootTargetIP,
+			TargetPort:    bootTargetPort,
+			Timeout:       bootTimeout,
+			PostReadyTime: postReadyTime,
+		}
+	}
+
+	// Idle characterization: passive sampling until stable, then Duration of steady state
+	if form.Get("test_type") == "idle" {
+		settleTime, _ := time.ParseDuration(form.Get("idle_settle_time"))
+		maxSettleTime, _ := time.ParseDuration(form.Get("idle_max_settle_time"))
+
+		config.Description = "Web UI Idle Characterization"
+		config.LoadEnabled = false
+		config.Idle = &runner.IdleConfig{
+			SettleTime:    settleTime,
+			MaxSettleTime: maxSettleTime,
+			Stability:     parseStabilityCriterion(form, "idle_"),
+		}
+		// Defaults that hold enough readings at the poll interval
+		if form.Get("idle_window") == "" {
+			config.Idle.Stability.Window = runner.IdleStabilityWindow(config.Interval)
+		}
+		if durationStr == "" {
+			config.Duration = runner.MinIdleDuration(config.Interval)
+		}
+		if err := config.Idle.Validate(config.Interval, config.Duration); err != nil {
+			return nil, err
+		}
+	}
 
-		result, err := s.runner.RunTest(ctx, config, updateChan)
+	// Test plan: a sequence of labelled steps with per-interface load
+	if form.Get("test_type") == "plan" {
+		plan, err := runner.ParsePlan([]byte(form.Get("test_plan")))
+		if err == nil && plan.HasLoad() && targetIP == "" && targetMAC == "" {
+			err = fmt.Errorf("test plan generates load but no target is set")
+		}
 		if err != nil {
-			log.Printf("Test failed: %v", err)
-		} else {
-			log.Printf("Test finished. Collected %d data points.", len(result.DataPoints))
-
-			// Save to database
-			if s.db != nil {
-				if err := s.saveTestToDatabase(result); err != nil {
-					log.Printf("Failed to save test to database: %v", err)
-				} else {
-					log.Printf("Test saved to database successfully")
-				}
-			}
+			return nil, err
+		}
+
+		config.Description = "Web UI Test Plan"
+		if plan.Name != "" {
+			config.Description += ": " + plan.Name
 		}
-		close(updateChan)
+		config.Duration = plan.TotalDuration()
+		config.PreTestTime = 0
+		config.PostTestTime = 0
+		config.LoadEnabled = plan.HasLoad()
+		config.Plan = plan
+	}
+
+	// Steady-state gating of the load test phases
+	if form.Get("steady_state") == "on" && config.Boot == nil && config.Idle == nil {
+		maxExtension, err := time.ParseDuration(form.Get("steady_max_extension"))
+		if err != nil {
+			maxExtension = 10 * time.Minute
+		}
+		config.SteadyState = &runner.SteadyStateConfig{
+			Stability:    parseStabilityCriterion(form, "steady_"),
+			MaxExtension: maxExtension,
+		}
+	}
+
+	// Safety abort rules of the load test phases
+	if form.Get("abort_enabled") == "on" && config.Boot == nil && config.Idle == nil {
+		config.Abort = parseAbortRules(form)
+	}
+
+	// Hooks: a JSON list of commands and HTTP calls
+	if hooksJSON := form.Get("hooks"); form.Get("hooks_enabled") == "on" && hooksJSON != "" {
+		hooks, err := runner.ParseHooks([]byte(hooksJSON))
+		if err != nil {
+			return nil, err
+		}
+		if runner.HasCommands(hooks) && !s.hookCommands {
+			return nil, fmt.Errorf("hook commands are disabled, start the server with -hook-commands")
+		}
+		config.Hooks = hooks
+	}
+
+	// Test matrix: one run per parameter combination, saved under a shared campaign ID
+	seriesID := time.Now().Format("20060102-150405")
+	var runs []runner.MatrixRun
+	if form.Get("test_type") == "matrix" {
+		matrix, err := parseMatrixConfig(form)
+		if err == nil {
+			config.Description = "Web UI Test Matrix"
+			runs, err = runner.ExpandMatrix(config, matrix, seriesID)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Repeated trials: every run is repeated, the repetitions share a group ID
+	repetitions := 1
+	if v, err := strconv.Atoi(form.Get("repetitions")); err == nil && v > 1 {
+		repetitions = v
+	}
+	repetitionGap, _ := time.ParseDuration(form.Get("repetition_gap"))
+	if repetitions > 1 {
+		if runs == nil {
+			runs = []runner.MatrixRun{{Config: config}}
+		}
+		runs = runner.ExpandRepetitions(runs, repetitions, seriesID)
+	}
+
+	job := &testJob{config: config, runs: runs, gap: repetitionGap}
+	return job, nil
+}
+
+// runJob runs a claimed test or series, saves the results and releases the runner
+func (s *Server) runJob(ctx context.Context, job *testJob) error {
+	defer func() {
+		s.mu.Lock()
+		s.cancel = nil
+		s.mu.Unlock()
+		s.broker.Broadcast([]byte("event: done\ndata: Test finished\n\n"))
 	}()
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("Test started"))
+	updateChan := make(chan runner.DataPoint)
+	
+	// Forward updates to SSE broker
+	go func() {
+		for dp := range updateChan {
+			data, _ := json.Marshal(dp)
+			msg := fmt.Sprintf("data: %s\n\n", data)
+			s.broker.Broadcast([]byte(msg))
+		}
+	}()
+
+	defer close(updateChan)
+
+	if job.runs != nil {
+		return s.runSeries(ctx, job.runs, job.gap, updateChan)
+	}
+
+	// With a database the recorder saves the test, also if it fails
+	result, err := s.runner.RunTest(ctx, job.config, updateChan)
+	if err == nil && result.AbortReason != "" {
+		err = fmt.Errorf("safety abort: %s", result.AbortReason)
+	}
+	if err != nil {
+		log.Printf("Test failed: %v", err)
+	} else {
+		log.Printf("Test finished. Collected %d data points.", len(result.DataPoints))
+	}
+	return err
+}
+
+// parseStabilityCriterion reads <prefix>window, <prefix>max_slope and <prefix>max_stddev,
+// falling back to the default criterion
+func parseStabilityCriterion(form url.Values, prefix string) runner.StabilityCriterion {
+	stability := runner.DefaultStabilityCriterion()
+	if window, err := time.ParseDuration(form.Get(prefix + "window")); err == nil && window > 0 {
+		stability.Window = window
+	}
+	if v, err := strconv.ParseFloat(form.Get(prefix+"max_slope"), 64); err == nil {
+		stability.MaxSlopeMWPerMin = v
+	}
+	if v, err := strconv.ParseFloat(form.Get(prefix+"max_stddev"), 64); err == nil {
+		stability.MaxStdDevMW = v
+	}
+	return stability
+}
+
+// parseAbortRules reads the abort_* form values. It returns nil if no rule is set.
+func parseAbortRules(form url.Values) *runner.AbortRules {
+	var rules runner.AbortRules
+	rules.MaxPowerMW, _ = strconv.ParseFloat(form.Get("abort_max_power"), 64)
+	rules.MinPowerMW, _ = strconv.ParseFloat(form.Get("abort_min_power"), 64)
+	rules.MinThroughputPercent, _ = strconv.ParseFloat(form.Get("abort_min_throughput_pct"), 64)
+	rules.ThroughputGrace, _ = time.ParseDuration(form.Get("abort_throughput_grace"))
+	rules.MaxFailedReads, _ = strconv.Atoi(form.Get("abort_max_failed_reads"))
+	rules.TargetPort, _ = strconv.Atoi(form.Get("abort_target_port"))
+	rules.MaxTargetFailures, _ = strconv.Atoi(form.Get("abort_max_target_failures"))
+
+	if rules.MaxPowerMW <= 0 && rules.MinPowerMW <= 0 && rules.MinThroughputPercent <= 0 &&
+		rules.MaxFailedReads <= 0 && rules.TargetPort <= 0 {
+		return nil
+	}
+	return &rules
 }
 
 func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
@@ -251,8 +515,7 @@ func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
 	defer s.mu.Unlock()
 
 	if s.cancel != nil {
-		s.cancel()
-		s.cancel = nil
+		s.cancel() // runJob releases the runner once the test has stopped
 		w.Write([]byte("Test stopped"))
 	} else {
 		w.Write([]byte("No test running"))
@@ -426,27 +689,41 @@ func (b *Broker) Broadcast(msg []byte) {
 	b.messages <- msg
 }
 
-// saveTestToDatabase saves a test result to the database
-func (s *Server) saveTestToDatabase(result *runner.TestResult) error {
+// newTestRecord builds the database record of a test result, including its summary
+func newTestRecord(result *runner.TestResult) (*database.TestRecord, error) {
 	// Marshal config and data to JSON
 	configJSON, err := json.Marshal(result.Config)
 	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+		return nil, fmt.Errorf("failed to marshal config: %w", err)
 	}
 
 	dataJSON, err := json.Marshal(result.DataPoints)
 	if err != nil {
-		return fmt.Errorf("failed to marshal data: %w", err)
+		return nil, fmt.Errorf("failed to marshal data: %w", err)
 	}
 
 	// Calculate summary statistics
-	summary := s.calculateTestSummary(result)
+	summary := CalculateTestSummary(result)
 	summaryJSON, err := json.Marshal(summary)
 	if err != nil {
-		return fmt.Errorf("failed to marshal summary: %w", err)
+		return nil, fmt.Errorf("failed to marshal summary: %w", err)
 	}
 
-	// Create test record
+	var throughputJSON []byte
+	if len(result.ThroughputSamples) > 0 {
+		if throughputJSON, err = json.Marshal(result.ThroughputSamples); err != nil {
+			return nil, fmt.Errorf("failed to marshal throughput samples: %w", err)
+		}
+	}
+
+	var hooksJSON []byte
+	if len(result.Hooks) > 0 {
+		if hooksJSON, err = json.Marshal(result.Hooks); err != nil {
+			return nil, fmt.Errorf("failed to marshal hook results: %w", err)
+		}
+	}
+
+	// Create test record, a safety abort is saved as aborted
 	record := &database.TestRecord{
 		TestName:   result.Config.TestName,
 		DeviceName: result.Config.DeviceName,
@@ -454,17 +731,25 @@ func (s *Server) saveTestToDatabase(result *runner.TestResult) error {
 		Config:     string(configJSON),
 		Data:       string(dataJSON),
 		Summary:    string(summaryJSON),
+		Hooks:      string(hooksJSON),
+		Throughput: string(throughputJSON),
+		CampaignID: result.Config.CampaignID,
+		GroupID:    result.Config.GroupID,
+		Status:     database.TestCompleted,
 	}
-
-	_, err = s.db.SaveTest(record)
-	return err
+	if result.AbortReason != "" {
+		record.Status = database.TestAborted
+		record.Error = result.AbortReason
+	}
+	return record, nil
 }
 
-// calculateTestSummary calculates summary statistics from test data
-func (s *Server) calculateTestSummary(result *runner.TestResult) *database.TestSummary {
+// CalculateTestSummary calculates summary statistics from test data
+func CalculateTestSummary(result *runner.TestResult) *database.TestSummary {
 	summary := &database.TestSummary{
 		DurationSeconds: result.EndTime.Sub(result.StartTime).Seconds(),
 		PhaseStats:      make(map[string]database.PhaseStats),
+		AbortReason:     result.AbortReason,
 	}
 
 	if len(result.DataPoints) == 0 {
@@ -478,15 +763,45 @@ func (s *Server) calculateTestSummary(result *runner.TestResult) *database.TestS
 
 	// Group data points by phase
 	phaseData := make(map[runner.Phase][]runner.DataPoint)
-
+	phaseExcluded := make(map[runner.Phase]int)
+	phaseFirst := make(map[runner.Phase]time.Time)
+	phaseLast := make(map[runner.Phase]time.Time)
+
+	// Stale and missing readings are counted but excluded from all statistics,
+	// repeated (not refreshed) readings are excluded from the power statistics
+	summary.SampleQuality = make(map[string]int)
+	var validCount, freshCount int
 	for _, dp := range result.DataPoints {
-		// Overall stats
-		totalPower += dp.PowerMW
-		if dp.PowerMW < minPower {
-			minPower = dp.PowerMW
+		quality := dp.Quality
+		if quality == "" {
+			quality = runner.QualityOK
 		}
-		if dp.PowerMW > maxPower {
-			maxPower = dp.PowerMW
+		summary.SampleQuality[string(quality)]++
+
+		if _, ok := phaseFirst[dp.Phase]; !ok {
+			phaseFirst[dp.Phase] = dp.Timestamp
+		}
+		phaseLast[dp.Phase] = dp.Timestamp
+
+		if !dp.Valid() {
+			summary.ExcludedDataPoints++
+			phaseExcluded[dp.Phase]++
+			continue
+		}
+		validCount++
+
+		// Overall stats
+		if dp.Repeated {
+			summary.RepeatedDataPoints++
+		} else {
+			freshCount++
+			totalPower += dp.PowerMW
+			if dp.PowerMW < minPower {
+				minPower = dp.PowerMW
+			}
+			if dp.PowerMW > maxPower {
+				maxPower = dp.PowerMW
+			}
 		}
 		totalThroughput += dp.ThroughputMbps
 		if dp.ThroughputMbps > maxThroughput {
@@ -497,12 +812,17 @@ func (s *Server) calculateTestSummary(result *runner.TestResult) *database.TestS
 		phaseData[dp.Phase] = append(phaseData[dp.Phase], dp)
 	}
 
-	summary.AveragePowerMW = totalPower / float64(len(result.DataPoints))
-	summary.MinPowerMW = minPower
-	summary.MaxPowerMW = maxPower
-	summary.AverageThroughputMbps = totalThroughput / float64(len(result.DataPoints))
-	summary.MaxThroughputMbps = maxThroughput
 	summary.TotalDataPoints = len(result.DataPoints)
+	if validCount == 0 {
+		return summary
+	}
+	if freshCount > 0 {
+		summary.AveragePowerMW = totalPower / float64(freshCount)
+		summary.MinPowerMW = minPower
+		summary.MaxPowerMW = maxPower
+	}
+	summary.AverageThroughputMbps = totalThroughput / float64(validCount)
+	summary.MaxThroughputMbps = maxThroughput
 
 	// Calculate per-phase statistics
 	for phase, points := range phaseData {
@@ -512,15 +832,22 @@ func (s *Server) calculateTestSummary(result *runner.TestResult) *database.TestS
 
 		var powerSum, throughputSum float64
 		var powerValues, throughputValues []float64
+		var freshPoints []runner.DataPoint
 
 		for _, dp := range points {
-			powerSum += dp.PowerMW
+			if dp.FreshPower() {
+				powerSum += dp.PowerMW
+				powerValues = append(powerValues, dp.PowerMW)
+				freshPoints = append(freshPoints, dp)
+			}
 			throughputSum += dp.ThroughputMbps
-			powerValues = append(powerValues, dp.PowerMW)
 			throughputValues = append(throughputValues, dp.ThroughputMbps)
 		}
 
-		avgPower := powerSum / float64(len(points))
+		var avgPower float64
+		if len(powerValues) > 0 {
+			avgPower = powerSum / float64(len(powerValues))
+		}
 		avgThroughput := throughputSum / float64(len(points))
 
 		// Calculate standard deviation
@@ -534,23 +861,214 @@ func (s *Server) calculateTestSummary(result *runner.TestResult) *database.TestS
 			throughputVariance += diff * diff
 		}
 
-		powerStdDev := math.Sqrt(powerVariance / float64(len(points)))
+		var powerStdDev float64
+		if len(powerValues) > 0 {
+			powerStdDev = math.Sqrt(powerVariance / float64(len(powerValues)))
+		}
 		throughputStdDev := math.Sqrt(throughputVariance / float64(len(points)))
 
 		phaseName := string(phase)
-		summary.PhaseStats[phaseName] = database.PhaseStats{
-			DurationSeconds:       float64(len(points)) * result.Config.Interval.Seconds(),
-			AveragePowerMW:        avgPower,
-			PowerStdDevMW:         powerStdDev,
-			AverageThroughputMbps: avgThroughput,
-			ThroughputStdDevMbps:  throughputStdDev,
-			DataPointCount:        len(points),
+		stats := database.PhaseStats{
+			DurationSeconds:        phaseLast[phase].Sub(phaseFirst[phase]).Seconds() + result.Config.Interval.Seconds(),
+			AveragePowerMW:         avgPower,
+			PowerStdDevMW:          powerStdDev,
+			AverageThroughputMbps:  avgThroughput,
+			ThroughputStdDevMbps:   throughputStdDev,
+			DataPointCount:         len(points),
+			ExcludedDataPointCount: phaseExcluded[phase],
+			RepeatedDataPointCount: len(points) - len(freshPoints),
+		}
+
+		// Energy: counter delta between phase boundaries vs. integral of the samples.
+		// The boundaries also give the exact duration, e.g. of boot phases sampled
+		// without a fixed interval.
+		var phaseStart, phaseEnd time.Time
+		for _, b := range result.PhaseBoundaries {
+			if b.Phase != phase {
+				continue
+			}
+			phaseStart, phaseEnd = b.StartTime, b.EndTime
+			stats.DurationSeconds = phaseEnd.Sub(phaseStart).Seconds()
+			if b.StartEnergyWh != nil && b.EndEnergyWh != nil {
+				delta := *b.EndEnergyWh - *b.StartEnergyWh
+				stats.EnergyCounterWh = &delta
+			}
+		}
+		stats.EnergyIntegratedWh = runner.IntegrateEnergyWh(freshPoints, phaseStart, phaseEnd)
+		if stats.EnergyCounterWh != nil {
+			diff := math.Abs(*stats.EnergyCounterWh - stats.EnergyIntegratedWh)
+			tolerance := math.Max(energyCounterResolutionWh, stats.EnergyIntegratedWh*energyMismatchRatio)
+			if diff > tolerance {
+				stats.EnergyMismatch = true
+				log.Printf("Energy mismatch in %s phase: counter %.2f Wh vs. integrated %.2f Wh",
+					phaseName, *stats.EnergyCounterWh, stats.EnergyIntegratedWh)
+			}
+		}
+
+		stats.MeterStats = meterPhaseStats(points, phaseStart, phaseEnd)
+
+		summary.PhaseStats[phaseName] = stats
+	}
+
+	summary.MeterStats = meterStats(result.DataPoints)
+	applyThroughputSamples(summary, result.ThroughputSamples)
+
+	if plan := result.Config.Plan; plan != nil {
+		for _, step := range plan.Steps {
+			summary.Steps = append(summary.Steps, step.Label)
+		}
+	}
+
+	if i := result.Idle; i != nil {
+		summary.Idle = &database.IdleStats{
+			Stabilized:    i.Stabilized,
+			SettleSeconds: i.SettleSeconds,
+			SettleReason:  i.SettleReason,
+			MeanPowerMW:   i.MeanPowerMW,
+			NoiseFloorMW:  i.NoiseFloorMW,
+			CI95LowMW:     i.CI95LowMW,
+			CI95HighMW:    i.CI95HighMW,
+			SampleCount:   i.SampleCount,
+		}
+	}
+
+	if b := result.Boot; b != nil {
+		summary.Boot = &database.BootStats{
+			Ready:           b.Ready,
+			BootSeconds:     b.BootSeconds,
+			PeakPowerMW:     b.PeakPowerMW,
+			EnergyToReadyWh: b.EnergyToReadyWh,
+			OffPowerMW:      b.OffPowerMW,
+		}
+	}
+
+	// Meter delay behind the load, and phase averages with the delay removed
+	if lag, ok := estimateMeterLag(result); ok {
+		summary.MeterLagSeconds = &lag.Seconds
+		summary.MeterLagCorrelation = lag.Correlation
+		summary.MeterLagEvents = lag.Events
+		log.Printf("Estimated meter lag: %.1fs (correlation %.2f over %d load changes)", lag.Seconds, lag.Correlation, lag.Events)
+
+		if lag.Seconds > 0 {
+			for phase, avg := range lagCorrectedPhasePower(result.DataPoints, lag.Lag()) {
+				if stats, ok := summary.PhaseStats[string(phase)]; ok {
+					stats.LagCorrectedAveragePowerMW = &avg
+					summary.PhaseStats[string(phase)] = stats
+				}
+			}
 		}
 	}
 
 	return summary
 }
 
+// applyThroughputSamples replaces the throughput statistics of the phases with
+// throughput samples by those of the samples, which resolve load changes within
+// a poll interval. The overall average stays the one of the data points, whose
+// throughput is the mean over each poll interval.
+func applyThroughputSamples(summary *database.TestSummary, samples []runner.ThroughputSample) {
+	if len(samples) == 0 {
+		return
+	}
+	summary.ThroughputSampleCount = len(samples)
+
+	values := make(map[runner.Phase][]float64)
+	for _, s := range samples {
+		values[s.Phase] = append(values[s.Phase], s.ThroughputMbps)
+		summary.MaxThroughputMbps = math.Max(summary.MaxThroughputMbps, s.ThroughputMbps)
+	}
+	for phase, mbps := range values {
+		stats, ok := summary.PhaseStats[string(phase)]
+		if !ok {
+			continue // No valid data points in this phase
+		}
+		var sum, variance float64
+		for _, v := range mbps {
+			sum += v
+		}
+		mean := sum / float64(len(mbps))
+		for _, v := range mbps {
+			variance += (v - mean) * (v - mean)
+		}
+		stats.AverageThroughputMbps = mean
+		stats.ThroughputStdDevMbps = math.Sqrt(variance / float64(len(mbps)))
+		stats.ThroughputSampleCount = len(mbps)
+		summary.PhaseStats[string(phase)] = stats
+	}
+}
+
+// meterSeries returns the readings of an additional meter as data points with
+// PowerMW set to that meter's power, skipping ticks where it could not be read
+func meterSeries(points []runner.DataPoint) map[string][]runner.DataPoint {
+	series := make(map[string][]runner.DataPoint)
+	for _, dp := range points {
+		for name, power := range dp.PowerByMeter {
+			series[name] = append(series[name], runner.DataPoint{Timestamp: dp.Timestamp, PowerMW: power, Phase: dp.Phase})
+		}
+	}
+	return series
+}
+
+// meterStats calculates overall statistics for each additional meter
+func meterStats(points []runner.DataPoint) map[string]database.MeterStats {
+	series := meterSeries(points)
+	if len(series) == 0 {
+		return nil
+	}
+
+	stats := make(map[string]database.MeterStats, len(series))
+	for name, mp := range series {
+		ms := database.MeterStats{MinPowerMW: math.MaxFloat64}
+		var sum float64
+		for _, dp := range mp {
+			sum += dp.PowerMW
+			ms.MinPowerMW = math.Min(ms.MinPowerMW, dp.PowerMW)
+			ms.MaxPowerMW = math.Max(ms.MaxPowerMW, dp.PowerMW)
+		}
+		ms.AveragePowerMW = sum / float64(len(mp))
+		stats[name] = ms
+	}
+	return stats
+}
+
+// meterPhaseStats calculates per-phase statistics for each additional meter
+func meterPhaseStats(points []runner.DataPoint, start, end time.Time) map[string]database.MeterPhaseStats {
+	series := meterSeries(points)
+	if len(series) == 0 {
+		return nil
+	}
+
+	stats := make(map[string]database.MeterPhaseStats, len(series))
+	for name, mp := range series {
+		var sum float64
+		for _, dp := range mp {
+			sum += dp.PowerMW
+		}
+		avg := sum / float64(len(mp))
+
+		var variance float64
+		for _, dp := range mp {
+			diff := dp.PowerMW - avg
+			variance += diff * diff
+		}
+
+		stats[name] = database.MeterPhaseStats{
+			AveragePowerMW:     avg,
+			PowerStdDevMW:      math.Sqrt(variance / float64(len(mp))),
+			EnergyIntegratedWh: runner.IntegrateEnergyWh(mp, start, end),
+			DataPointCount:     len(mp),
+		}
+	}
+	return stats
+}
+
+const (
+	// energyCounterResolutionWh is the resolution of the smart plug's energy counter
+	energyCounterResolutionWh = 1.0
+	// energyMismatchRatio is the relative difference above which counter and integral are flagged
+	energyMismatchRatio = 0.05
+)
+
 // handleListTests returns all saved tests
 func (s *Server) handleListTests(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
@@ -570,6 +1088,10 @@ func (s *Server) handleListTests(w http.ResponseWriter, r *http.Request) {
 		TestName   string    `json:"test_name"`
 		DeviceName string    `json:"device_name"`
 		Timestamp  time.Time `json:"timestamp"`
+		CampaignID string    `json:"campaign_id,omitempty"`
+		GroupID    string    `json:"group_id,omitempty"`
+		Status     string    `json:"status"`
+		Error      string    `json:"error,omitempty"`
 		CreatedAt  time.Time `json:"created_at"`
 	}
 
@@ -580,6 +1102,10 @@ func (s *Server) handleListTests(w http.ResponseWriter, r *http.Request) {
 			TestName:   test.TestName,
 			DeviceName: test.DeviceName,
 			Timestamp:  test.Timestamp,
+			CampaignID: test.CampaignID,
+			GroupID:    test.GroupID,
+			Status:     test.Status,
+			Error:      test.Error,
 			CreatedAt:  test.CreatedAt,
 		})
 	}
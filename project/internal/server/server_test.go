@@ -0,0 +1,174 @@
+package server
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"project/internal/runner"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+
+// summaryPoint is a data point offset from lagTestStart
+func summaryPoint(offset time.Duration, phase runner.Phase, powerMW float64, quality runner.SampleQuality) runner.DataPoint {
+	return runner.DataPoint{
+		Timestamp: lagTestStart.Add(offset),
+		PowerMW:   powerMW,
+		Quality:   quality,
+		Phase:     phase,
+	}
+}
+
+func TestCalculateTestSummary(t *testing.T) {
+	const ok = runner.QualityOK
+	repeated := summaryPoint(50*time.Second, runner.PhaseLoad, 9000, ok)
+	repeated.Repeated = true
+	result := &runner.TestResult{
+		Config:    runner.TestConfig{Interval: 10 * time.Second},
+		StartTime: lagTestStart,
+		EndTime:   lagTestStart.Add(70 * time.Second),
+		DataPoints: []runner.DataPoint{
+			summaryPoint(0, runner.PhasePreTest, 5000, ok),
+			summaryPoint(10*time.Second, runner.PhasePreTest, 0, runner.QualityMissing),
+			summaryPoint(20*time.Second, runner.PhasePreTest, 7000, runner.QualityRetried),
+			summaryPoint(30*time.Second, runner.PhaseLoad, 8000, ok),
+			summaryPoint(40*time.Second, runner.PhaseLoad, 10000, ok),
+			repeated,
+			summaryPoint(60*time.Second, runner.PhasePostTest, 6000, runner.QualityStale),
+		},
+	}
+	for i := range result.DataPoints {
+		if result.DataPoints[i].Phase == runner.PhaseLoad {
+			result.DataPoints[i].ThroughputMbps = 900
+		}
+	}
+
+	summary := CalculateTestSummary(result)
+	if summary.DurationSeconds != 70 || summary.TotalDataPoints != 7 {
+		t.Errorf("duration %v s, %d data points", summary.DurationSeconds, summary.TotalDataPoints)
+	}
+	// Missing and stale readings are excluded, repeated ones only from power
+	if summary.ExcludedDataPoints != 2 || summary.RepeatedDataPoints != 1 {
+		t.Errorf("excluded %d, repeated %d, want 2 and 1", summary.ExcludedDataPoints, summary.RepeatedDataPoints)
+	}
+	if summary.AveragePowerMW != 7500 || summary.MinPowerMW != 5000 || summary.MaxPowerMW != 10000 {
+		t.Errorf("power avg %v min %v max %v, want 7500, 5000, 10000", summary.AveragePowerMW, summary.MinPowerMW, summary.MaxPowerMW)
+	}
+	if summary.AverageThroughputMbps != 540 || summary.MaxThroughputMbps != 900 {
+		t.Errorf("throughput avg %v max %v, want 540 and 900", summary.AverageThroughputMbps, summary.MaxThroughputMbps)
+	}
+	if q := summary.SampleQuality; q["ok"] != 4 || q["retried"] != 1 || q["missing"] != 1 || q["stale"] != 1 {
+		t.Errorf("sample quality = %v", q)
+	}
+
+	tests := []struct {
+		phase    runner.Phase
+		duration float64
+		avgPower float64
+		points   int
+		excluded int
+		repeated int
+	}{
+		{runner.PhasePreTest, 30, 6000, 2, 1, 0},
+		{runner.PhaseLoad, 30, 9000, 3, 0, 1},
+	}
+	for _, tt := range tests {
+		stats, found := summary.PhaseStats[string(tt.phase)]
+		if !found {
+			t.Errorf("%s: no phase stats", tt.phase)
+			continue
+		}
+		if stats.DurationSeconds != tt.duration || stats.AveragePowerMW != tt.avgPower ||
+			stats.DataPointCount != tt.points || stats.ExcludedDataPointCount != tt.excluded || stats.RepeatedDataPointCount != tt.repeated {
+			t.Errorf("%s: got %+v", tt.phase, stats)
+		}
+	}
+	// A phase without valid readings has no statistics
+	if _, found := summary.PhaseStats[string(runner.PhasePostTest)]; found {
+		t.Error("post phase without valid readings has stats")
+	}
+}
+
+func TestCalculateTestSummaryPhaseDuration(t *testing.T) {
+	// Boot phases are sampled as fast as the meter answers, without an interval
+	boot := &runner.TestResult{
+		DataPoints: []runner.DataPoint{
+			summaryPoint(0, runner.PhaseOff, 0, runner.QualityOK),
+			summaryPoint(4*time.Second, runner.PhaseOff, 0, runner.QualityOK),
+			summaryPoint(5*time.Second, runner.PhaseBoot, 9000, runner.QualityOK),
+			summaryPoint(5700*time.Millisecond, runner.PhaseBoot, 0, runner.QualityMissing),
+			summaryPoint(6500*time.Millisecond, runner.PhaseBoot, 12000, runner.QualityOK),
+			summaryPoint(37*time.Second, runner.PhaseBoot, 8000, runner.QualityOK),
+		},
+	}
+	summary := CalculateTestSummary(boot)
+	if d := summary.PhaseStats[string(runner.PhaseOff)].DurationSeconds; d != 4 {
+		t.Errorf("off phase: %v s, want 4", d)
+	}
+	if d := summary.PhaseStats[string(runner.PhaseBoot)].DurationSeconds; d != 32 {
+		t.Errorf("boot phase: %v s, want 32", d)
+	}
+
+	// Phase boundaries give the exact duration of a load test's phase
+	load := &runner.TestResult{
+		Config: runner.TestConfig{Interval: 10 * time.Second},
+		DataPoints: []runner.DataPoint{
+			summaryPoint(10*time.Second, runner.PhaseLoad, 9000, runner.QualityOK),
+			summaryPoint(20*time.Second, runner.PhaseLoad, 9000, runner.QualityOK),
+		},
+		PhaseBoundaries: []runner.PhaseBoundary{
+			{Phase: runner.PhaseLoad, StartTime: lagTestStart.Add(500 * time.Millisecond), EndTime: lagTestStart.Add(25 * time.Second)},
+		},
+	}
+	if d := CalculateTestSummary(load).PhaseStats[string(runner.PhaseLoad)].DurationSeconds; d != 24.5 {
+		t.Errorf("load phase: %v s, want 24.5 from the boundaries", d)
+	}
+}
+
+func TestCalculateTestSummaryEnergyMismatch(t *testing.T) {
+	// Tolerance is the larger of the counter resolution (1 Wh) and 5% of the integral
+	tests := []struct {
+		name      string
+		powerMW   float64
+		counterWh float64
+		mismatch  bool
+	}{
+		{"100 Wh within 5%", 100000, 104.9, false},
+		{"100 Wh beyond 5%", 100000, 105.1, true},
+		{"100 Wh counter low", 100000, 94.9, true},
+		{"10 Wh within 1 Wh", 10000, 10.9, false},
+		{"10 Wh beyond 1 Wh", 10000, 11.1, true},
+		{"1 Wh counter not advanced", 1000, 0, false},
+		{"2 Wh counter not advanced", 2000, 0, true},
+	}
+	for _, tt := range tests {
+		// Readings at the start and end of a one hour phase, held to the boundaries
+		start, end := lagTestStart, lagTestStart.Add(time.Hour)
+		result := &runner.TestResult{
+			Config: runner.TestConfig{Interval: 30 * time.Minute},
+			DataPoints: []runner.DataPoint{
+				summaryPoint(time.Minute, runner.PhaseLoad, tt.powerMW, runner.QualityOK),
+				summaryPoint(59*time.Minute, runner.PhaseLoad, tt.powerMW, runner.QualityOK),
+			},
+			PhaseBoundaries: []runner.PhaseBoundary{{
+				Phase:         runner.PhaseLoad,
+				StartTime:     start,
+				EndTime:       end,
+				StartEnergyWh: floatPtr(1000),
+				EndEnergyWh:   floatPtr(1000 + tt.counterWh),
+			}},
+		}
+
+		stats := CalculateTestSummary(result).PhaseStats[string(runner.PhaseLoad)]
+		if want := tt.powerMW / 1000; math.Abs(stats.EnergyIntegratedWh-want) > 1e-9 {
+			t.Errorf("%s: integrated %v Wh, want %v", tt.name, stats.EnergyIntegratedWh, want)
+		}
+		if stats.EnergyCounterWh == nil || math.Abs(*stats.EnergyCounterWh-tt.counterWh) > 1e-9 {
+			t.Errorf("%s: counter %v Wh, want %v", tt.name, stats.EnergyCounterWh, tt.counterWh)
+		}
+		if stats.EnergyMismatch != tt.mismatch {
+			t.Errorf("%s: mismatch %v, want %v", tt.name, stats.EnergyMismatch, tt.mismatch)
+		}
+	}
+}
@@ -1,9 +1,16 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"project/internal/database"
 	"project/internal/fritzbox"
@@ -20,48 +27,318 @@ func main() {
 		log.Println("No .env file found, using defaults or flags")
 	}
 
+	// Headless mode: run a single test from a config file without the web UI
+	if len(os.Args) > 1 && os.Args[1] == "run" {
+		os.Exit(runCommand(os.Args[2:]))
+	}
+
 	addr := flag.String("addr", ":8080", "Address to listen on")
-	mock := flag.Bool("mock", false, "Use mock power meter")
+	hookCommands := flag.Bool("hook-commands", false, "Allow tests started through the web UI or API to run local hook commands (default: $HOOK_COMMANDS=true)")
+	meterOpts := addMeterFlags(flag.CommandLine)
 	flag.Parse()
 
+	// Initialize database
+	db, dbPath, err := openDatabase()
+	if err != nil {
+		log.Fatalf("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+	log.Printf("Database initialized: %s", dbPath)
+
+	r, err := meterOpts.newRunner(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer r.Close()
+
+	srv := server.NewServer(r, db)
+	if *hookCommands || os.Getenv("HOOK_COMMANDS") == "true" {
+		log.Println("Hook commands enabled")
+		srv.AllowHookCommands()
+	}
+
+	// Release the meters and the database on Ctrl-C instead of just exiting
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		log.Printf("Starting server on %s", *addr)
+		if err := srv.Start(*addr); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	<-ctx.Done()
+	log.Println("Shutting down")
+}
+
+// openDatabase opens the test database at $DB_PATH (default tests.db)
+func openDatabase() (*database.Database, string, error) {
+	dbPath := os.Getenv("DB_PATH")
+	if dbPath == "" {
+		dbPath = "tests.db"
+	}
+	db, err := database.New(dbPath)
+	return db, dbPath, err
+}
+
+// meterFlags are the power meter options shared by the server and the run command
+type meterFlags struct {
+	mock        *bool
+	meterType   *string
+	replay      *string
+	replaySpeed *float64
+	replayLoop  *bool
+	extraMeters *string
+}
+
+func addMeterFlags(fs *flag.FlagSet) *meterFlags {
+	return &meterFlags{
+		mock:        fs.Bool("mock", false, "Use mock power meter"),
+		meterType:   fs.String("meter", "", "Power meter backend: tr064, aha, shelly, tasmota, httpjson, scpi, modbus or sim (default: $METER_TYPE or tr064)"),
+		replay:      fs.String("replay", "", "Replay a saved test as power meter: path to a CSV export or db:<test id>"),
+		replaySpeed: fs.Float64("replay-speed", 1, "Replay speed factor (e.g. 10 = ten times faster)"),
+		replayLoop:  fs.Bool("replay-loop", false, "Restart the replay at its beginning after the last point instead of holding it"),
+		extraMeters: fs.String("extra-meters", "", "Additional meters polled alongside the DUT as name=type,... configured via <NAME>_ prefixed settings (default: $EXTRA_METERS)"),
+	}
+}
+
+// newRunner creates the power meters selected by the flags and a runner using
+// them. The database is only needed to replay a saved test and may be nil.
+func (f *meterFlags) newRunner(db *database.Database) (*runner.Runner, error) {
+	lg := loadgen.NewNetworkLoadGenerator()
+
 	var meter fritzbox.PowerMeter
-	if *mock {
+	var err error
+	if *f.replay != "" {
+		log.Printf("Using Replay Power Meter (%s)", *f.replay)
+		replay, err := newReplayPowerMeter(*f.replay, *f.replaySpeed, db)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize replay power meter: %w", err)
+		}
+		replay.Loop = *f.replayLoop
+		meter = replay
+	} else if *f.mock {
 		log.Println("Using Mock Power Meter")
 		meter = fritzbox.NewMockPowerMeter()
 	} else {
-		log.Println("Using Real Power Meter")
+		meterType := *f.meterType
+		if meterType == "" {
+			meterType = os.Getenv("METER_TYPE")
+		}
+
+		meter, err = newPowerMeter(meterType, "", lg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize power meter: %w", err)
+		}
+	}
+
+	r := runner.NewRunner(meter, lg)
+
+	// Additional meters, e.g. for the load-generating PC or a PoE-powered access point
+	extraMeters := *f.extraMeters
+	if extraMeters == "" {
+		extraMeters = os.Getenv("EXTRA_METERS")
+	}
+	for _, entry := range strings.Split(extraMeters, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		name, extraType, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid extra meter %q, expected name=type", entry)
+		}
+		extra, err := newPowerMeter(extraType, meterEnv(strings.ToUpper(name)+"_"), lg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize meter %s: %w", name, err)
+		}
+		if err := r.AddMeter(name, extra); err != nil {
+			return nil, fmt.Errorf("failed to add meter %s: %w", name, err)
+		}
+		log.Printf("Added power meter %s (%s)", name, extraType)
+	}
 
-		url := os.Getenv("FRITZ_URL")
-		user := os.Getenv("FRITZ_USER")
-		pass := os.Getenv("FRITZ_PASSWORD")
-		ain := os.Getenv("FRITZ_AIN")
+	return r, nil
+}
+
+// newPowerMeter creates the power meter backend selected by meterType.
+// Settings are read from environment variables starting with env's prefix.
+// The load generator feeds the simulated DUT.
+func newPowerMeter(meterType string, env meterEnv, lg loadgen.LoadGenerator) (fritzbox.PowerMeter, error) {
+	url := env.get("FRITZ_URL")
+	user := env.get("FRITZ_USER")
+	pass := env.get("FRITZ_PASSWORD")
+	ain := env.get("FRITZ_AIN")
 
+	switch meterType {
+	case "", "tr064":
+		log.Println("Using Real Power Meter (TR-064)")
 		if url == "" {
 			url = "http://fritz.box:49000"
 		}
-
-		meter = fritzbox.NewRealPowerMeter(url, user, pass, ain)
+		return fritzbox.NewRealPowerMeter(url, user, pass, ain), nil
+	case "aha":
+		log.Println("Using Real Power Meter (AHA-HTTP)")
+		// AHA is served by the regular web interface, not the TR-064 port
+		if ahaURL := env.get("FRITZ_AHA_URL"); ahaURL != "" {
+			url = ahaURL
+		} else {
+			url = "http://fritz.box"
+		}
+		return fritzbox.NewAHAPowerMeter(url, user, pass, ain), nil
+	case "shelly":
+		log.Println("Using Shelly Power Meter")
+		shelly := fritzbox.NewShellyPowerMeter(env.get("METER_URL"), env.int("SHELLY_GEN", 2), env.int("SHELLY_CHANNEL", 0))
+		shelly.Username = env.get("METER_USER")
+		shelly.Password = env.get("METER_PASSWORD")
+		return shelly, nil
+	case "tasmota":
+		log.Println("Using Tasmota Power Meter")
+		tasmota := fritzbox.NewTasmotaPowerMeter(env.get("METER_URL"))
+		tasmota.Username = env.get("METER_USER")
+		tasmota.Password = env.get("METER_PASSWORD")
+		return tasmota, nil
+	case "httpjson":
+		log.Println("Using HTTP-JSON Power Meter")
+		powerPath := env.get("HTTPJSON_POWER_PATH")
+		if powerPath == "" {
+			return nil, fmt.Errorf("HTTPJSON_POWER_PATH is required for the httpjson meter")
+		}
+		hj := fritzbox.NewHTTPJSONPowerMeter(env.get("METER_URL"), powerPath, env.float("HTTPJSON_POWER_SCALE", 1000))
+		hj.Username = env.get("METER_USER")
+		hj.Password = env.get("METER_PASSWORD")
+		hj.VoltagePath = env.get("HTTPJSON_VOLTAGE_PATH")
+		hj.CurrentPath = env.get("HTTPJSON_CURRENT_PATH")
+		hj.EnergyPath = env.get("HTTPJSON_ENERGY_PATH")
+		hj.EnergyScale = env.float("HTTPJSON_ENERGY_SCALE", 1)
+		hj.PowerFactorPath = env.get("HTTPJSON_PF_PATH")
+		return hj, nil
+	case "scpi":
+		log.Println("Using SCPI Power Meter")
+		preset := env.get("SCPI_PRESET")
+		if preset == "" {
+			preset = "scpi"
+		}
+		commands, ok := fritzbox.SCPIPresets[preset]
+		if !ok {
+			return nil, fmt.Errorf("unknown SCPI preset %q", preset)
+		}
+		// Individual commands can be overridden for instruments without a preset
+		overrides := []struct {
+			env string
+			dst *string
+		}{
+			{"SCPI_POWER_CMD", &commands.Power},
+			{"SCPI_VOLTAGE_CMD", &commands.Voltage},
+			{"SCPI_CURRENT_CMD", &commands.Current},
+			{"SCPI_PF_CMD", &commands.PowerFactor},
+			{"SCPI_ENERGY_CMD", &commands.Energy},
+		}
+		for _, o := range overrides {
+			if v, set := env.lookup(o.env); set {
+				*o.dst = v
+			}
+		}
+		if initCmds := env.get("SCPI_INIT"); initCmds != "" {
+			commands.Init = strings.Split(initCmds, "|")
+		}
+		return fritzbox.NewSCPIPowerMeter(env.get("SCPI_ADDR"), env.int("SCPI_CHANNEL", 1), commands), nil
+	case "modbus":
+		log.Println("Using Modbus TCP Power Meter")
+		mapping := fritzbox.ModbusMapping{}
+		if preset := env.get("MODBUS_PRESET"); preset != "" {
+			var ok bool
+			if mapping, ok = fritzbox.ModbusPresets[preset]; !ok {
+				return nil, fmt.Errorf("unknown modbus preset %q", preset)
+			}
+		}
+		// Individual registers can be overridden for meters without a preset
+		overrides := []struct {
+			env string
+			dst **fritzbox.ModbusRegister
+		}{
+			{"MODBUS_POWER_REG", &mapping.Power},
+			{"MODBUS_VOLTAGE_REG", &mapping.Voltage},
+			{"MODBUS_CURRENT_REG", &mapping.Current},
+			{"MODBUS_PF_REG", &mapping.PowerFactor},
+			{"MODBUS_ENERGY_REG", &mapping.Energy},
+		}
+		for _, o := range overrides {
+			spec := env.get(o.env)
+			if spec == "" {
+				continue
+			}
+			reg, err := fritzbox.ParseModbusRegister(spec)
+			if err != nil {
+				return nil, fmt.Errorf("%s%s: %w", env, o.env, err)
+			}
+			*o.dst = reg
+		}
+		if mapping.Power == nil {
+			return nil, fmt.Errorf("MODBUS_PRESET or MODBUS_POWER_REG is required for the modbus meter")
+		}
+		return fritzbox.NewModbusPowerMeter(env.get("MODBUS_ADDR"), byte(env.int("MODBUS_UNIT", 1)), mapping), nil
+	case "sim":
+		log.Println("Using Simulated DUT Power Meter")
+		config := fritzbox.DefaultSimulatedDUTConfig()
+		config.IdlePowerMW = env.float("SIM_IDLE_MW", config.IdlePowerMW)
+		config.WattsPerGbps = env.float("SIM_W_PER_GBPS", config.WattsPerGbps)
+		config.NoiseMW = env.float("SIM_NOISE_MW", config.NoiseMW)
+		config.QuantizationMW = env.float("SIM_QUANT_MW", config.QuantizationMW)
+		if lag, err := time.ParseDuration(env.get("SIM_LAG")); err == nil {
+			config.Lag = lag
+		}
+		return fritzbox.NewSimulatedPowerMeter(lg, config), nil
+	default:
+		return nil, fmt.Errorf("unknown power meter type %q", meterType)
 	}
+}
 
-	lg := loadgen.NewNetworkLoadGenerator()
-	r := runner.NewRunner(meter, lg)
+// newReplayPowerMeter loads a replay source, either a CSV file or "db:<id>" for a saved test
+func newReplayPowerMeter(source string, speed float64, db *database.Database) (*fritzbox.ReplayPowerMeter, error) {
+	idStr, fromDB := strings.CutPrefix(source, "db:")
+	if !fromDB {
+		return fritzbox.NewReplayPowerMeterFromCSV(source, speed)
+	}
 
-	// Initialize database
-	dbPath := os.Getenv("DB_PATH")
-	if dbPath == "" {
-		dbPath = "tests.db"
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid test id %q", idStr)
 	}
-	db, err := database.New(dbPath)
+	if db == nil {
+		return nil, fmt.Errorf("replaying a saved test needs the database")
+	}
+	record, err := db.GetTest(id)
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		return nil, err
 	}
-	defer db.Close()
-	log.Printf("Database initialized: %s", dbPath)
+	return fritzbox.NewReplayPowerMeterFromRecord(record, speed)
+}
 
-	srv := server.NewServer(r, db)
+// meterEnv reads meter settings from environment variables with an optional
+// prefix, so additional meters can be configured as e.g. PC_METER_URL
+type meterEnv string
 
-	log.Printf("Starting server on %s", *addr)
-	if err := srv.Start(*addr); err != nil {
-		log.Fatal(err)
+func (e meterEnv) get(key string) string {
+	return os.Getenv(string(e) + key)
+}
+
+func (e meterEnv) lookup(key string) (string, bool) {
+	return os.LookupEnv(string(e) + key)
+}
+
+// int reads an integer setting, returning def if unset or invalid
+func (e meterEnv) int(key string, def int) int {
+	v, err := strconv.Atoi(e.get(key))
+	if err != nil {
+		return def
+	}
+	return v
+}
+
+// float reads a float setting, returning def if unset or invalid
+func (e meterEnv) float(key string, def float64) float64 {
+	v, err := strconv.ParseFloat(e.get(key), 64)
+	if err != nil {
+		return def
 	}
+	return v
 }
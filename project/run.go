@@ -0,0 +1,606 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"project/internal/database"
+	"project/internal/loadgen"
+	"project/internal/runner"
+	"project/internal/server"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Exit codes of the run command
+const (
+	exitOK         = 0
+	exitError      = 1 // Invalid config or setup failure
+	exitAborted    = 2 // Test stopped before its end (interrupt, runner error or safety rule)
+	exitIncomplete = 3 // Test ran to the end but its result is incomplete
+)
+
+// runFile is a headless test configuration read from JSON or YAML. Durations
+// are duration strings ("5m") or seconds. A file with top-level steps is a
+// test plan on its own.
+type runFile struct {
+	TestName     string              `json:"test_name"`
+	DeviceName   string              `json:"device_name"`
+	Duration     runner.PlanDuration `json:"duration"`
+	PollInterval runner.PlanDuration `json:"poll_interval"`
+	// Throughput sampling interval during load, independent of poll_interval
+	ThroughputInterval runner.PlanDuration `json:"throughput_interval"`
+	PreTestTime        runner.PlanDuration `json:"pre_test_time"`
+	PostTestTime       runner.PlanDuration `json:"post_test_time"`
+	ReadTimeout        runner.PlanDuration `json:"read_timeout"`
+	ReadRetries        *int                `json:"read_retries"`
+	RetryBackoff       runner.PlanDuration `json:"retry_backoff"`
+
+	Load        *runLoad         `json:"load"`
+	Plan        *runner.TestPlan `json:"plan"`
+	Idle        *runIdle         `json:"idle"`
+	Boot        *runBoot         `json:"boot"`
+	SteadyState *runSteadyState  `json:"steady_state"`
+	Abort       *runAbort        `json:"abort"`
+	Hooks       []runner.Hook    `json:"hooks"`
+
+	// Top-level plan fields, for plan files run as they are
+	Name  string            `json:"name"`
+	Steps []runner.PlanStep `json:"steps"`
+}
+
+// runLoad is the load generation config, as in the web UI
+type runLoad struct {
+	TargetIP   string         `json:"target_ip"`
+	TargetPort int            `json:"target_port"`
+	TargetMAC  string         `json:"target_mac"`
+	Protocol   string         `json:"protocol"`
+	PacketSize int            `json:"packet_size"`
+	Interfaces []runInterface `json:"interfaces"` // Empty = OS routing
+}
+
+type runInterface struct {
+	Name           string              `json:"name"`
+	Workers        int                 `json:"workers"`
+	ThroughputMbps float64             `json:"throughput_mbps"`
+	RampSteps      int                 `json:"ramp_steps"`
+	PreTime        runner.PlanDuration `json:"pre_time"`
+	RampDuration   runner.PlanDuration `json:"ramp_duration"`
+}
+
+// runStability overrides the default stability criterion
+type runStability struct {
+	Window      runner.PlanDuration `json:"window"`
+	MaxSlope    *float64            `json:"max_slope_mw_per_min"`
+	MaxStdDevMW *float64            `json:"max_stddev_mw"`
+}
+
+type runIdle struct {
+	SettleTime    runner.PlanDuration `json:"settle_time"`
+	MaxSettleTime runner.PlanDuration `json:"max_settle_time"`
+	Stability     runStability        `json:"stability"`
+}
+
+type runBoot struct {
+	OffTime       runner.PlanDuration `json:"off_time"`
+	TargetIP      string              `json:"target_ip"` // Default: load target
+	TargetPort    int                 `json:"target_port"`
+	Timeout       runner.PlanDuration `json:"timeout"`
+	PostReadyTime runner.PlanDuration `json:"post_ready_time"`
+}
+
+type runSteadyState struct {
+	MaxExtension runner.PlanDuration `json:"max_extension"`
+	Stability    runStability        `json:"stability"`
+}
+
+// runAbort are the safety abort rules, zero values disable a rule
+type runAbort struct {
+	MaxPowerMW           float64             `json:"max_power_mw"`
+	MinPowerMW           float64             `json:"min_power_mw"`
+	MinThroughputPercent float64             `json:"min_throughput_percent"`
+	ThroughputGrace      runner.PlanDuration `json:"throughput_grace"`
+	MaxFailedReads       int                 `json:"max_failed_reads"`
+	TargetPort           int                 `json:"target_port"`
+	MaxTargetFailures    int                 `json:"max_target_failures"`
+}
+
+// criterion applies the overrides to the default stability criterion
+func (s runStability) criterion() runner.StabilityCriterion {
+	stability := runner.DefaultStabilityCriterion()
+	if s.Window > 0 {
+		stability.Window = time.Duration(s.Window)
+	}
+	if s.MaxSlope != nil {
+		stability.MaxSlopeMWPerMin = *s.MaxSlope
+	}
+	if s.MaxStdDevMW != nil {
+		stability.MaxStdDevMW = *s.MaxStdDevMW
+	}
+	return stability
+}
+
+// readRunFile reads a JSON or YAML (.yaml, .yml) config file
+func readRunFile(path string) (*runFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// YAML is converted to JSON so both share the duration parsing and field names
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".yaml" || ext == ".yml" {
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("failed to convert %s: %w", path, err)
+		}
+	}
+
+	var file runFile
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return &file, nil
+}
+
+// testConfig builds the runner config, with the same defaults as the web UI
+func (f *runFile) testConfig() (runner.TestConfig, error) {
+	config := runner.TestConfig{
+		Duration:     time.Duration(f.Duration),
+		Interval:     time.Duration(f.PollInterval),
+		PreTestTime:  time.Duration(f.PreTestTime),
+		PostTestTime: time.Duration(f.PostTestTime),
+		Description:  "Headless Test",
+		TestName:     f.TestName,
+		DeviceName:   f.DeviceName,
+		ReadTimeout:  time.Duration(f.ReadTimeout),
+		ReadRetries:  2,
+		RetryBackoff: time.Duration(f.RetryBackoff),
+
+		ThroughputInterval: time.Duration(f.ThroughputInterval),
+	}
+	if config.TestName == "" {
+		config.TestName = "Unnamed Test"
+	}
+	if config.DeviceName == "" {
+		config.DeviceName = "Unknown Device"
+	}
+	if config.Duration == 0 {
+		config.Duration = 1 * time.Minute
+	}
+	if config.Interval == 0 {
+		config.Interval = 60 * time.Second
+	}
+	if config.ThroughputInterval > 0 && config.ThroughputInterval < runner.MinThroughputInterval {
+		return config, fmt.Errorf("throughput_interval must be at least %s", runner.MinThroughputInterval)
+	}
+	if f.ReadRetries != nil && *f.ReadRetries >= 0 {
+		config.ReadRetries = *f.ReadRetries
+	}
+
+	if f.Load != nil {
+		config.LoadEnabled = true
+		config.LoadConfig = loadgen.Config{
+			TargetIP:   f.Load.TargetIP,
+			TargetPort: f.Load.TargetPort,
+			Protocol:   f.Load.Protocol,
+			TargetMAC:  f.Load.TargetMAC,
+			PacketSize: f.Load.PacketSize,
+		}
+		if config.LoadConfig.TargetPort == 0 {
+			config.LoadConfig.TargetPort = 9 // Default discard
+		}
+		if config.LoadConfig.Protocol == "" {
+			config.LoadConfig.Protocol = "udp"
+		}
+		if config.LoadConfig.PacketSize == 0 {
+			config.LoadConfig.PacketSize = 1400
+		}
+		for _, ri := range f.Load.Interfaces {
+			ic := loadgen.InterfaceConfig{
+				Name:             ri.Name,
+				Workers:          ri.Workers,
+				TargetThroughput: ri.ThroughputMbps,
+				RampSteps:        ri.RampSteps,
+				PreTime:          time.Duration(ri.PreTime),
+				RampDuration:     time.Duration(ri.RampDuration),
+			}
+			if ic.Workers == 0 {
+				ic.Workers = 10
+			}
+			config.LoadConfig.InterfaceConfigs = append(config.LoadConfig.InterfaceConfigs, ic)
+		}
+		if len(config.LoadConfig.InterfaceConfigs) == 0 {
+			config.LoadConfig.InterfaceConfigs = []loadgen.InterfaceConfig{{Workers: 16}}
+		}
+	}
+
+	plan := f.Plan
+	if len(f.Steps) > 0 {
+		if plan != nil {
+			return config, fmt.Errorf("config has both a plan and top-level steps")
+		}
+		plan = &runner.TestPlan{Name: f.Name, Steps: f.Steps}
+	}
+
+	modes := 0
+	for _, set := range []bool{plan != nil, f.Idle != nil, f.Boot != nil} {
+		if set {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return config, fmt.Errorf("plan, idle and boot are mutually exclusive")
+	}
+
+	switch {
+	case plan != nil:
+		if err := plan.Validate(); err != nil {
+			return config, err
+		}
+		if plan.HasLoad() && (f.Load == nil || (f.Load.TargetIP == "" && f.Load.TargetMAC == "")) {
+			return config, fmt.Errorf("test plan generates load but no target is set")
+		}
+		config.Description = "Headless Test Plan"
+		config.Plan = plan
+		config.Duration = plan.TotalDuration()
+		config.PreTestTime = 0
+		config.PostTestTime = 0
+		if plan.Name != "" && f.TestName == "" {
+			config.TestName = plan.Name
+		}
+	case f.Idle != nil:
+		config.Description = "Headless Idle Characterization"
+		config.LoadEnabled = false
+		config.Idle = &runner.IdleConfig{
+			SettleTime:    time.Duration(f.Idle.SettleTime),
+			MaxSettleTime: time.Duration(f.Idle.MaxSettleTime),
+			Stability:     f.Idle.Stability.criterion(),
+		}
+		// Defaults that hold enough readings at the poll interval
+		if f.Idle.Stability.Window == 0 {
+			config.Idle.Stability.Window = runner.IdleStabilityWindow(config.Interval)
+		}
+		if f.Duration == 0 {
+			config.Duration = runner.MinIdleDuration(config.Interval)
+		}
+		if err := config.Idle.Validate(config.Interval, config.Duration); err != nil {
+			return config, err
+		}
+	case f.Boot != nil:
+		boot := &runner.BootConfig{
+			OffTime:       time.Duration(f.Boot.OffTime),
+			TargetIP:      f.Boot.TargetIP,
+			TargetPort:    f.Boot.TargetPort,
+			Timeout:       time.Duration(f.Boot.Timeout),
+			PostReadyTime: time.Duration(f.Boot.PostReadyTime),
+		}
+		if boot.OffTime == 0 {
+			boot.OffTime = 10 * time.Second
+		}
+		if boot.TargetIP == "" && f.Load != nil {
+			boot.TargetIP = f.Load.TargetIP
+		}
+		if boot.TargetPort == 0 {
+			boot.TargetPort = 80 // Web interface
+		}
+		config.Description = "Headless Boot Test"
+		config.LoadEnabled = false
+		config.Boot = boot
+		config.Interval = time.Duration(f.PollInterval) // As fast as the meter allows unless set
+	}
+
+	if f.SteadyState != nil && config.Boot == nil && config.Idle == nil {
+		config.SteadyState = &runner.SteadyStateConfig{
+			Stability:    f.SteadyState.Stability.criterion(),
+			MaxExtension: time.Duration(f.SteadyState.MaxExtension),
+		}
+		if config.SteadyState.MaxExtension == 0 {
+			config.SteadyState.MaxExtension = 10 * time.Minute
+		}
+	}
+
+	if err := runner.ValidateHooks(f.Hooks); err != nil {
+		return config, err
+	}
+	config.Hooks = f.Hooks
+
+	if f.Abort != nil && config.Boot == nil && config.Idle == nil {
+		config.Abort = &runner.AbortRules{
+			MaxPowerMW:           f.Abort.MaxPowerMW,
+			MinPowerMW:           f.Abort.MinPowerMW,
+			MinThroughputPercent: f.Abort.MinThroughputPercent,
+			ThroughputGrace:      time.Duration(f.Abort.ThroughputGrace),
+			MaxFailedReads:       f.Abort.MaxFailedReads,
+			TargetPort:           f.Abort.TargetPort,
+			MaxTargetFailures:    f.Abort.MaxTargetFailures,
+		}
+	}
+
+	return config, nil
+}
+
+// runCommand runs one test from a config file and returns the exit code
+func runCommand(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	configPath := fs.String("config", "", "Test config or test plan (JSON or YAML)")
+	out := fs.String("out", "", "Output path prefix for <prefix>.csv and <prefix>.json (default: <test name>_<start time>)")
+	save := fs.Bool("save", false, "Save the test to the database ($DB_PATH)")
+	meterOpts := addMeterFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s run [flags] <config.json|config.yaml>\n\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *configPath == "" {
+		*configPath = fs.Arg(0)
+	}
+	if *configPath == "" {
+		fs.Usage()
+		return exitError
+	}
+
+	file, err := readRunFile(*configPath)
+	if err != nil {
+		log.Print(err)
+		return exitError
+	}
+	config, err := file.testConfig()
+	if err != nil {
+		log.Printf("Invalid test config: %v", err)
+		return exitError
+	}
+
+	var db *database.Database
+	if *save || strings.HasPrefix(*meterOpts.replay, "db:") {
+		var dbPath string
+		if db, dbPath, err = openDatabase(); err != nil {
+			log.Printf("Failed to initialize database: %v", err)
+			return exitError
+		}
+		defer db.Close()
+		log.Printf("Database initialized: %s", dbPath)
+	}
+
+	r, err := meterOpts.newRunner(db)
+	if err != nil {
+		log.Print(err)
+		return exitError
+	}
+	defer r.Close()
+	if config.Boot != nil && !r.CanSwitch() {
+		log.Print("Power meter cannot switch its outlet")
+		return exitError
+	}
+	if *save {
+		// Streamed while the test runs, so an interrupted run is finalized by the
+		// next server start and an aborted one is saved with its partial data
+		r.SetRecorder(server.NewTestRecorder(db))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	updateChan := make(chan runner.DataPoint, 16)
+	progressDone := make(chan struct{})
+	go func() {
+		printProgress(updateChan)
+		close(progressDone)
+	}()
+
+	result, runErr := r.RunTest(ctx, config, updateChan)
+	close(updateChan)
+	<-progressDone
+
+	if result == nil {
+		log.Printf("Test failed: %v", runErr)
+		return exitAborted
+	}
+
+	prefix := *out
+	if prefix == "" {
+		prefix = fmt.Sprintf("%s_%s", fileNameSafe(config.TestName), result.StartTime.Format("20060102-150405"))
+	}
+	summary := server.CalculateTestSummary(result)
+	if err := writeResultFiles(prefix, result, summary); err != nil {
+		log.Printf("Failed to write results: %v", err)
+		return exitError
+	}
+	log.Printf("Results written to %s.csv and %s.json", prefix, prefix)
+
+	if runErr != nil {
+		log.Printf("Test aborted: %v", runErr)
+		return exitAborted
+	}
+
+	if result.AbortReason != "" {
+		log.Printf("Test aborted by safety rule: %s", result.AbortReason)
+		return exitAborted
+	}
+
+	if reasons := incompleteReasons(result, summary); len(reasons) > 0 {
+		log.Printf("Test incomplete: %s", strings.Join(reasons, "; "))
+		return exitIncomplete
+	}
+	return exitOK
+}
+
+// printProgress prints one line per data point and the events recorded with it
+func printProgress(updates <-chan runner.DataPoint) {
+	var start time.Time
+	for dp := range updates {
+		if start.IsZero() {
+			start = dp.Timestamp
+		}
+		quality := ""
+		if dp.Quality != "" && dp.Quality != runner.QualityOK {
+			quality = " [" + string(dp.Quality) + "]"
+		}
+		fmt.Printf("%8s  %-10s %10.1f mW %10.1f Mbps%s\n",
+			dp.Timestamp.Sub(start).Round(time.Second), dp.Phase, dp.PowerMW, dp.ThroughputMbps, quality)
+		for _, evt := range dp.Events {
+			fmt.Printf("          [%s] %s\n", evt.Type, evt.Message)
+		}
+	}
+}
+
+// incompleteReasons lists why a test that ran to its end is still incomplete
+func incompleteReasons(result *runner.TestResult, summary *database.TestSummary) []string {
+	var reasons []string
+	if len(result.DataPoints) == 0 {
+		reasons = append(reasons, "no data points")
+	}
+	if missing := summary.SampleQuality[string(runner.QualityMissing)]; missing > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d missing readings", missing))
+	}
+	if result.Idle != nil && !result.Idle.Stabilized {
+		reasons = append(reasons, "power did not stabilize: "+result.Idle.SettleReason)
+	}
+	if result.Boot != nil && !result.Boot.Ready {
+		reasons = append(reasons, "target not ready within the boot timeout")
+	}
+	return reasons
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fileNameSafe turns a test name into a file name
+func fileNameSafe(name string) string {
+	return strings.Trim(unsafeFileChars.ReplaceAllString(name, "_"), "_")
+}
+
+// writeResultFiles writes <prefix>.csv in the web UI's export format (it can be
+// replayed with -replay) and <prefix>.json with config, summary and data
+func writeResultFiles(prefix string, result *runner.TestResult, summary *database.TestSummary) error {
+	csvFile, err := os.Create(prefix + ".csv")
+	if err != nil {
+		return err
+	}
+	defer csvFile.Close()
+	if err := writeResultCSV(csvFile, result); err != nil {
+		return err
+	}
+
+	report := struct {
+		Config          runner.TestConfig         `json:"config"`
+		Summary         *database.TestSummary     `json:"summary"`
+		PhaseBoundaries []runner.PhaseBoundary    `json:"phase_boundaries"`
+		Hooks           []runner.HookResult       `json:"hooks,omitempty"`
+		DataPoints      []runner.DataPoint        `json:"data_points"`
+		Throughput      []runner.ThroughputSample `json:"throughput_samples,omitempty"`
+		StartTime       time.Time                 `json:"start_time"`
+		EndTime         time.Time                 `json:"end_time"`
+	}{result.Config, summary, result.PhaseBoundaries, result.Hooks, result.DataPoints, result.ThroughputSamples, result.StartTime, result.EndTime}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(prefix+".json", data, 0644); err != nil {
+		return err
+	}
+	return csvFile.Close()
+}
+
+// writeResultCSV writes the data points with the same columns as the web UI export
+func writeResultCSV(f *os.File, result *runner.TestResult) error {
+	config := result.Config
+	fmt.Fprintf(f, "# Power Consumption Test Report\n")
+	fmt.Fprintf(f, "# Generated: %s\n", time.Now().Format(time.RFC3339))
+	fmt.Fprintf(f, "# Test: %s (%s)\n", config.TestName, config.DeviceName)
+	fmt.Fprintf(f, "# Duration: %s\n", config.Duration)
+	fmt.Fprintf(f, "# Poll Interval: %s\n", config.Interval)
+	fmt.Fprintf(f, "# Pre-Test Baseline: %s\n", config.PreTestTime)
+	fmt.Fprintf(f, "# Post-Test Baseline: %s\n", config.PostTestTime)
+	fmt.Fprintf(f, "# Load Enabled: %t\n", config.LoadEnabled)
+	fmt.Fprintf(f, "#\n")
+
+	interfaceSet := make(map[string]bool)
+	meterSet := make(map[string]bool)
+	for _, dp := range result.DataPoints {
+		for iface := range dp.ThroughputByInterface {
+			interfaceSet[iface] = true
+		}
+		for meter := range dp.PowerByMeter {
+			meterSet[meter] = true
+		}
+	}
+	interfaces := sortedKeys(interfaceSet)
+	meters := sortedKeys(meterSet)
+
+	header := []string{"Timestamp", "ElapsedSeconds", "PowerMW"}
+	for _, meter := range meters {
+		header = append(header, "Power_"+meter+"_MW")
+	}
+	header = append(header, "ThroughputTotalMbps", "TargetThroughputTotalMbps")
+	for _, iface := range interfaces {
+		header = append(header, "Throughput_"+iface+"_Mbps", "Target_"+iface+"_Mbps")
+	}
+	header = append(header, "Quality", "Repeated", "Phase", "Events")
+
+	w := csv.NewWriter(f)
+	w.Write(header)
+	formatFloat := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	for _, dp := range result.DataPoints {
+		row := []string{
+			dp.Timestamp.Format(time.RFC3339Nano),
+			formatFloat(dp.Timestamp.Sub(result.StartTime).Seconds()),
+			formatFloat(dp.PowerMW),
+		}
+		for _, meter := range meters {
+			// Leave the cell empty when the meter could not be read
+			if power, ok := dp.PowerByMeter[meter]; ok {
+				row = append(row, formatFloat(power))
+			} else {
+				row = append(row, "")
+			}
+		}
+		targetTotal := 0.0
+		for _, iface := range interfaces {
+			targetTotal += dp.TargetThroughputByInterface[iface]
+		}
+		row = append(row, formatFloat(dp.ThroughputMbps), formatFloat(targetTotal))
+		for _, iface := range interfaces {
+			row = append(row, formatFloat(dp.ThroughputByInterface[iface]), formatFloat(dp.TargetThroughputByInterface[iface]))
+		}
+		quality := string(dp.Quality)
+		if quality == "" {
+			quality = string(runner.QualityOK)
+		}
+		var events []string
+		for _, evt := range dp.Events {
+			events = append(events, fmt.Sprintf("[%s] %s", evt.Type, evt.Message))
+		}
+		row = append(row, quality, strconv.FormatBool(dp.Repeated), string(dp.Phase), strings.Join(events, " | "))
+		w.Write(row)
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
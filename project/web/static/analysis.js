@@ -110,8 +110,15 @@ function createTestListItem(test) {
     const date = new Date(test.timestamp);
     const dateStr = date.toLocaleString();
 
+    // Tests that did not run to completion keep their data points up to the end
+    let statusBadge = '';
+    if (test.status && test.status !== 'completed') {
+        const title = test.error ? ` title="${test.error}"` : '';
+        statusBadge = ` <span class="badge badge-warning"${title}>${test.status}</span>`;
+    }
+
     item.innerHTML = `
-        <div class="title">${test.test_name}</div>
+        <div class="title">${test.test_name}${statusBadge}</div>
         <div class="details">
             Device: ${test.device_name} | Date: ${dateStr}
         </div>
@@ -156,14 +163,24 @@ async function loadSelectedTest() {
         const testRecord = await response.json();
 
         const config = JSON.parse(testRecord.config);
-        const data = JSON.parse(testRecord.data);
+        // Data points and throughput samples have their own time bases, both are
+        // placed on the time axis relative to the test start
+        const start = new Date(testRecord.timestamp).getTime();
+        const withElapsed = p => ({
+            ...p,
+            timestamp: new Date(p.timestamp),
+            elapsed_seconds: (new Date(p.timestamp).getTime() - start) / 1000
+        });
+        const data = JSON.parse(testRecord.data).map(withElapsed);
+        const throughputSamples = JSON.parse(testRecord.throughput || '[]').map(withElapsed);
 
         currentTestData = {
             testName: testRecord.test_name,
             deviceName: testRecord.device_name,
             timestamp: new Date(testRecord.timestamp),
             config: config,
-            dataPoints: data
+            dataPoints: data,
+            throughputSamples: throughputSamples
         };
 
         analyzeAndDisplayTest();
@@ -735,7 +752,16 @@ function renderAllCharts(dataPoints, stats) {
         x: dp.elapsed_seconds,
         y: dp.power_mw / 1000
     }));
-    const throughputData = dataPoints.map(dp => ({
+    // Throughput samples taken faster than the power readings show the load in full detail
+    const throughputSamples = currentTestData?.throughputSamples || [];
+    let throughputSeries = dataPoints;
+    if (throughputSamples.length > 0) {
+        const sampledPhases = new Set(throughputSamples.map(s => s.phase));
+        throughputSeries = dataPoints.filter(dp => !sampledPhases.has(dp.phase))
+            .concat(throughputSamples)
+            .sort((a, b) => a.elapsed_seconds - b.elapsed_seconds);
+    }
+    const throughputData = throughputSeries.map(dp => ({
         x: dp.elapsed_seconds,
         y: dp.throughput_mbps || 0
     }));
@@ -194,9 +194,43 @@ document.addEventListener('DOMContentLoaded', () => {
                 deviceName: document.getElementById('device_name')?.value,
                 duration: document.getElementById('duration')?.value,
                 pollInterval: document.getElementById('poll_interval')?.value,
+                throughputInterval: document.getElementById('throughput_interval')?.value,
                 preTestTime: document.getElementById('pre_test_time')?.value,
                 postTestTime: document.getElementById('post_test_time')?.value,
                 powerYMin: document.getElementById('power_y_min')?.value,
+                readTimeout: document.getElementById('read_timeout')?.value,
+                readRetries: document.getElementById('read_retries')?.value,
+                testType: document.getElementById('test_type')?.value,
+                bootTargetIP: document.getElementById('boot_target_ip')?.value,
+                bootTargetPort: document.getElementById('boot_target_port')?.value,
+                bootOffTime: document.getElementById('boot_off_time')?.value,
+                bootTimeout: document.getElementById('boot_timeout')?.value,
+                idleSettleTime: document.getElementById('idle_settle_time')?.value,
+                idleMaxSettleTime: document.getElementById('idle_max_settle_time')?.value,
+                idleWindow: document.getElementById('idle_window')?.value,
+                idleMaxSlope: document.getElementById('idle_max_slope')?.value,
+                idleMaxStdDev: document.getElementById('idle_max_stddev')?.value,
+                testPlan: document.getElementById('test_plan')?.value,
+                repetitions: document.getElementById('repetitions')?.value,
+                repetitionGap: document.getElementById('repetition_gap')?.value,
+                matrixProtocols: document.getElementById('matrix_protocols')?.value,
+                matrixPacketSizes: document.getElementById('matrix_packet_sizes')?.value,
+                matrixPortCounts: document.getElementById('matrix_port_counts')?.value,
+                matrixCooldown: document.getElementById('matrix_cooldown')?.value,
+                steadyState: document.getElementById('steady_state')?.checked,
+                steadyWindow: document.getElementById('steady_window')?.value,
+                steadyMaxExtension: document.getElementById('steady_max_extension')?.value,
+                steadyMaxSlope: document.getElementById('steady_max_slope')?.value,
+                steadyMaxStdDev: document.getElementById('steady_max_stddev')?.value,
+                abortEnabled: document.getElementById('abort_enabled')?.checked,
+                abortMaxPower: document.getElementById('abort_max_power')?.value,
+                abortMinPower: document.getElementById('abort_min_power')?.value,
+                abortMinThroughputPct: document.getElementById('abort_min_throughput_pct')?.value,
+                abortThroughputGrace: document.getElementById('abort_throughput_grace')?.value,
+                abortMaxFailedReads: document.getElementById('abort_max_failed_reads')?.value,
+                abortTargetPort: document.getElementById('abort_target_port')?.value,
+                hooksEnabled: document.getElementById('hooks_enabled')?.checked,
+                hooks: document.getElementById('hooks')?.value,
                 loadEnabled: document.getElementById('load_enabled')?.checked,
                 targetIP: document.getElementById('target_ip')?.value,
                 targetPort: document.getElementById('target_port')?.value,
@@ -241,8 +275,42 @@ document.addEventListener('DOMContentLoaded', () => {
             if (config.deviceName) document.getElementById('device_name').value = config.deviceName;
             if (config.duration) document.getElementById('duration').value = config.duration;
             if (config.pollInterval) document.getElementById('poll_interval').value = config.pollInterval;
+            if (config.throughputInterval !== undefined) document.getElementById('throughput_interval').value = config.throughputInterval;
             if (config.preTestTime) document.getElementById('pre_test_time').value = config.preTestTime;
             if (config.postTestTime) document.getElementById('post_test_time').value = config.postTestTime;
+            if (config.readTimeout) document.getElementById('read_timeout').value = config.readTimeout;
+            if (config.readRetries) document.getElementById('read_retries').value = config.readRetries;
+            if (config.testType) document.getElementById('test_type').value = config.testType;
+            if (config.bootTargetIP) document.getElementById('boot_target_ip').value = config.bootTargetIP;
+            if (config.bootTargetPort) document.getElementById('boot_target_port').value = config.bootTargetPort;
+            if (config.bootOffTime) document.getElementById('boot_off_time').value = config.bootOffTime;
+            if (config.bootTimeout) document.getElementById('boot_timeout').value = config.bootTimeout;
+            if (config.idleSettleTime) document.getElementById('idle_settle_time').value = config.idleSettleTime;
+            if (config.idleMaxSettleTime) document.getElementById('idle_max_settle_time').value = config.idleMaxSettleTime;
+            if (config.idleWindow) document.getElementById('idle_window').value = config.idleWindow;
+            if (config.idleMaxSlope) document.getElementById('idle_max_slope').value = config.idleMaxSlope;
+            if (config.idleMaxStdDev) document.getElementById('idle_max_stddev').value = config.idleMaxStdDev;
+            if (config.testPlan) document.getElementById('test_plan').value = config.testPlan;
+            if (config.repetitions) document.getElementById('repetitions').value = config.repetitions;
+            if (config.repetitionGap) document.getElementById('repetition_gap').value = config.repetitionGap;
+            if (config.matrixProtocols !== undefined) document.getElementById('matrix_protocols').value = config.matrixProtocols;
+            if (config.matrixPacketSizes !== undefined) document.getElementById('matrix_packet_sizes').value = config.matrixPacketSizes;
+            if (config.matrixPortCounts !== undefined) document.getElementById('matrix_port_counts').value = config.matrixPortCounts;
+            if (config.matrixCooldown) document.getElementById('matrix_cooldown').value = config.matrixCooldown;
+            if (config.steadyState !== undefined) document.getElementById('steady_state').checked = config.steadyState;
+            if (config.steadyWindow) document.getElementById('steady_window').value = config.steadyWindow;
+            if (config.steadyMaxExtension) document.getElementById('steady_max_extension').value = config.steadyMaxExtension;
+            if (config.steadyMaxSlope) document.getElementById('steady_max_slope').value = config.steadyMaxSlope;
+            if (config.steadyMaxStdDev) document.getElementById('steady_max_stddev').value = config.steadyMaxStdDev;
+            if (config.abortEnabled !== undefined) document.getElementById('abort_enabled').checked = config.abortEnabled;
+            if (config.abortMaxPower) document.getElementById('abort_max_power').value = config.abortMaxPower;
+            if (config.abortMinPower) document.getElementById('abort_min_power').value = config.abortMinPower;
+            if (config.abortMinThroughputPct) document.getElementById('abort_min_throughput_pct').value = config.abortMinThroughputPct;
+            if (config.abortThroughputGrace) document.getElementById('abort_throughput_grace').value = config.abortThroughputGrace;
+            if (config.abortMaxFailedReads) document.getElementById('abort_max_failed_reads').value = config.abortMaxFailedReads;
+            if (config.abortTargetPort) document.getElementById('abort_target_port').value = config.abortTargetPort;
+            if (config.hooksEnabled !== undefined) document.getElementById('hooks_enabled').checked = config.hooksEnabled;
+            if (config.hooks) document.getElementById('hooks').value = config.hooks;
             if (config.powerYMin) {
                 document.getElementById('power_y_min').value = config.powerYMin;
                 // Trigger change event to update chart
@@ -332,6 +400,63 @@ document.addEventListener('DOMContentLoaded', () => {
         updateLoadConfigVisibility();
     }
 
+    // Toggle boot and idle test config visibility
+    const testTypeSelect = document.getElementById('test_type');
+    const bootConfigDiv = document.getElementById('boot_config');
+    const idleConfigDiv = document.getElementById('idle_config');
+    const planConfigDiv = document.getElementById('plan_config');
+    const matrixConfigDiv = document.getElementById('matrix_config');
+    function updateTestTypeConfigVisibility() {
+        if (!testTypeSelect) return;
+        if (bootConfigDiv) bootConfigDiv.style.display = testTypeSelect.value === 'boot' ? 'block' : 'none';
+        if (idleConfigDiv) idleConfigDiv.style.display = testTypeSelect.value === 'idle' ? 'block' : 'none';
+        if (planConfigDiv) planConfigDiv.style.display = testTypeSelect.value === 'plan' ? 'block' : 'none';
+        if (matrixConfigDiv) matrixConfigDiv.style.display = testTypeSelect.value === 'matrix' ? 'block' : 'none';
+    }
+    if (testTypeSelect) {
+        testTypeSelect.addEventListener('change', updateTestTypeConfigVisibility);
+        updateTestTypeConfigVisibility();
+    }
+
+    // Toggle steady-state gating config visibility
+    const steadyStateCheckbox = document.getElementById('steady_state');
+    const steadyConfigDiv = document.getElementById('steady_config');
+    function updateSteadyConfigVisibility() {
+        if (steadyStateCheckbox && steadyConfigDiv) {
+            steadyConfigDiv.style.display = steadyStateCheckbox.checked ? 'block' : 'none';
+        }
+    }
+    if (steadyStateCheckbox) {
+        steadyStateCheckbox.addEventListener('change', updateSteadyConfigVisibility);
+        updateSteadyConfigVisibility();
+    }
+
+    // Toggle safety abort rule config visibility
+    const abortCheckbox = document.getElementById('abort_enabled');
+    const abortConfigDiv = document.getElementById('abort_config');
+    function updateAbortConfigVisibility() {
+        if (abortCheckbox && abortConfigDiv) {
+            abortConfigDiv.style.display = abortCheckbox.checked ? 'block' : 'none';
+        }
+    }
+    if (abortCheckbox) {
+        abortCheckbox.addEventListener('change', updateAbortConfigVisibility);
+        updateAbortConfigVisibility();
+    }
+
+    // Toggle hooks config visibility
+    const hooksCheckbox = document.getElementById('hooks_enabled');
+    const hooksConfigDiv = document.getElementById('hooks_config');
+    function updateHooksConfigVisibility() {
+        if (hooksCheckbox && hooksConfigDiv) {
+            hooksConfigDiv.style.display = hooksCheckbox.checked ? 'block' : 'none';
+        }
+    }
+    if (hooksCheckbox) {
+        hooksCheckbox.addEventListener('change', updateHooksConfigVisibility);
+        updateHooksConfigVisibility();
+    }
+
     // Helper to wait
     const wait = (ms) => new Promise(resolve => setTimeout(resolve, ms));
 
@@ -695,6 +820,7 @@ document.addEventListener('DOMContentLoaded', () => {
     let startTime = null;
     let collectedData = [];
     let currentPhase = '';
+    let fastThroughput = false; // Throughput samples received for the running test
     let lastTestConfig = null; // Store config when test starts for CSV export
 
     // Phase colors for charts
@@ -734,7 +860,10 @@ document.addEventListener('DOMContentLoaded', () => {
         'ramp': { border: 'rgba(255, 159, 64, 0.8)', dash: [3, 3] },
         'iface_start': { border: 'rgba(54, 162, 235, 0.8)', dash: [2, 2] },
         'iface_stop': { border: 'rgba(153, 102, 255, 0.8)', dash: [2, 2] },
-        'custom': { border: 'rgba(255, 99, 132, 1)', dash: [] }
+        'custom': { border: 'rgba(255, 99, 132, 1)', dash: [] },
+        'control': { border: 'rgba(108, 117, 125, 0.9)', dash: [6, 2] },
+        'abort': { border: 'rgba(220, 53, 69, 1)', dash: [] },
+        'hook': { border: 'rgba(32, 201, 151, 0.9)', dash: [4, 4] }
     };
 
     // Add event annotation to charts
@@ -934,6 +1063,14 @@ document.addEventListener('DOMContentLoaded', () => {
     
     // Calculate total duration and build event timeline
     function updateProgressTracking(config) {
+        if (config.plan && Array.isArray(config.plan.steps)) {
+            updatePlanProgressTracking(config.plan);
+            return;
+        }
+        if (config.matrixRuns > 0) {
+            updateMatrixProgressTracking(config);
+            return;
+        }
         const preTest = parseDuration(config.preTestTime || '0s');
         const loadTest = parseDuration(config.duration || '0s');
         const postTest = parseDuration(config.postTestTime || '0s');
@@ -993,6 +1130,55 @@ document.addEventListener('DOMContentLoaded', () => {
         testStartTime = Date.now();
     }
     
+    // Progress timeline of a test plan: one entry per step
+    function updatePlanProgressTracking(plan) {
+        eventTimeline = [];
+        let currentTime = 0;
+        plan.steps.forEach(step => {
+            eventTimeline.push({ time: currentTime, description: `${step.label} Start` });
+            const duration = step.duration;
+            currentTime += typeof duration === 'number' ? duration : parseDuration(duration || '0s');
+        });
+        eventTimeline.push({ time: currentTime, description: 'Test Complete' });
+        testTotalDuration = currentTime;
+        testStartTime = Date.now();
+    }
+
+    // Progress timeline of a test matrix: one entry per run, cooldown extends the post-test baseline
+    function updateMatrixProgressTracking(config) {
+        const preTest = parseDuration(config.preTestTime || '0s');
+        const loadTest = parseDuration(config.duration || '0s');
+        const postTest = parseDuration(config.postTestTime || '0s');
+        const cooldown = Math.max(postTest, parseDuration(config.matrixCooldown || '0s'));
+
+        eventTimeline = [];
+        let currentTime = 0;
+        for (let i = 0; i < config.matrixRuns; i++) {
+            eventTimeline.push({ time: currentTime, description: `Run ${i + 1}/${config.matrixRuns} Start` });
+            currentTime += preTest + loadTest + (i < config.matrixRuns - 1 ? cooldown : postTest);
+        }
+        eventTimeline.push({ time: currentTime, description: 'Test Complete' });
+        testTotalDuration = currentTime;
+        testStartTime = Date.now();
+    }
+
+    // Repeats the progress timeline for repeated trials, with the gap between repetitions
+    function applyRepetitionsToProgress(config) {
+        const repetitions = config.repetitions || 1;
+        if (repetitions <= 1) return;
+        const single = testTotalDuration;
+        const period = single + parseDuration(config.repetitionGap || '0s');
+        const singleTimeline = eventTimeline;
+        eventTimeline = [];
+        for (let i = 0; i < repetitions; i++) {
+            singleTimeline.forEach(evt => {
+                if (evt.description === 'Test Complete' && i < repetitions - 1) return;
+                eventTimeline.push({ time: evt.time + i * period, description: `${evt.description} (run ${i + 1}/${repetitions})` });
+            });
+        }
+        testTotalDuration = period * (repetitions - 1) + single;
+    }
+
     // Update progress UI
     function updateProgressUI(elapsedSeconds, phaseName) {
         const progressPercent = Math.min(100, (elapsedSeconds / testTotalDuration * 100).toFixed(1));
@@ -1031,6 +1217,69 @@ document.addEventListener('DOMContentLoaded', () => {
         document.getElementById('eventsList').textContent = eventsText;
     }
 
+    // Append a point to the throughput chart. Filled from the data points, or
+    // from the separate throughput samples when the server sends them.
+    function appendThroughputPoint(elapsedSeconds, throughputMbps, throughputByInterface, targetThroughputByInterface) {
+        // Add label (time) to chart
+        throughputChart.data.labels.push(elapsedSeconds);
+        
+        // Calculate total target throughput for this data point
+        const totalTarget = Object.values(targetThroughputByInterface).reduce((sum, val) => sum + val, 0);
+        
+        // Update total throughput (first dataset, dashed line)
+        throughputChart.data.datasets[0].data.push(throughputMbps);
+        
+        // Update per-interface throughput datasets (actual throughput)
+        for (const [ifaceName, ifaceThroughput] of Object.entries(throughputByInterface)) {
+            const datasetIndex = getOrCreateInterfaceDataset(ifaceName);
+            // Backfill with nulls if this interface was added late
+            while (throughputChart.data.datasets[datasetIndex].data.length < throughputChart.data.labels.length - 1) {
+                throughputChart.data.datasets[datasetIndex].data.push(null);
+            }
+            throughputChart.data.datasets[datasetIndex].data.push(ifaceThroughput);
+        }
+        
+        // Add/update target throughput datasets (dotted lines)
+        for (const [ifaceName, targetThroughput] of Object.entries(targetThroughputByInterface)) {
+            const targetDatasetLabel = `${ifaceName} (target)`;
+            let targetDatasetIndex = throughputChart.data.datasets.findIndex(ds => ds.label === targetDatasetLabel);
+            
+            if (targetDatasetIndex === -1) {
+                // Create new target dataset with dotted line
+                const actualDatasetIndex = throughputChart.data.datasets.findIndex(ds => ds.label === ifaceName);
+                const color = actualDatasetIndex >= 0 ? throughputChart.data.datasets[actualDatasetIndex].borderColor : 'rgba(150, 150, 150, 0.6)';
+                
+                throughputChart.data.datasets.push({
+                    label: targetDatasetLabel,
+                    data: [],
+                    borderColor: color,
+                    backgroundColor: 'transparent',
+                    fill: false,
+                    borderWidth: 1,
+                    borderDash: [5, 5],
+                    pointRadius: 0,
+                    tension: 0
+                });
+                targetDatasetIndex = throughputChart.data.datasets.length - 1;
+            }
+            
+            // Backfill with nulls if needed
+            while (throughputChart.data.datasets[targetDatasetIndex].data.length < throughputChart.data.labels.length - 1) {
+                throughputChart.data.datasets[targetDatasetIndex].data.push(null);
+            }
+            throughputChart.data.datasets[targetDatasetIndex].data.push(targetThroughput);
+        }
+        
+        // Ensure all datasets have same length (fill with null for missing data)
+        for (let i = 0; i < throughputChart.data.datasets.length; i++) {
+            while (throughputChart.data.datasets[i].data.length < throughputChart.data.labels.length) {
+                throughputChart.data.datasets[i].data.push(null);
+            }
+        }
+        
+        throughputChart.update();
+    }
+
     // Connect to SSE
     function connectSSE() {
         if (eventSource) {
@@ -1063,75 +1312,19 @@ document.addEventListener('DOMContentLoaded', () => {
 
             // Update Power Chart
             powerChart.data.labels.push(elapsedSeconds);
-            powerChart.data.datasets[0].data.push(data.power_mw);
+            // Missing readings are shown as gaps
+            powerChart.data.datasets[0].data.push(data.quality === 'missing' ? null : data.power_mw);
             powerChart.update();
 
             // Update Throughput Chart with per-interface data
             const throughputMbps = data.throughput_mbps || 0;
             const throughputByInterface = data.throughput_by_interface || {};
             const targetThroughputByInterface = data.target_throughput_by_interface || {};
-            
-            // Add label (time) to chart
-            throughputChart.data.labels.push(elapsedSeconds);
-            
-            // Calculate total target throughput for this data point
-            const totalTarget = Object.values(targetThroughputByInterface).reduce((sum, val) => sum + val, 0);
-            
-            // Update total throughput (first dataset, dashed line)
-            throughputChart.data.datasets[0].data.push(throughputMbps);
-            
-            // Update per-interface throughput datasets (actual throughput)
-            for (const [ifaceName, ifaceThroughput] of Object.entries(throughputByInterface)) {
-                const datasetIndex = getOrCreateInterfaceDataset(ifaceName);
-                // Backfill with nulls if this interface was added late
-                while (throughputChart.data.datasets[datasetIndex].data.length < throughputChart.data.labels.length - 1) {
-                    throughputChart.data.datasets[datasetIndex].data.push(null);
-                }
-                throughputChart.data.datasets[datasetIndex].data.push(ifaceThroughput);
-            }
-            
-            // Add/update target throughput datasets (dotted lines)
-            for (const [ifaceName, targetThroughput] of Object.entries(targetThroughputByInterface)) {
-                const targetDatasetLabel = `${ifaceName} (target)`;
-                let targetDatasetIndex = throughputChart.data.datasets.findIndex(ds => ds.label === targetDatasetLabel);
-                
-                if (targetDatasetIndex === -1) {
-                    // Create new target dataset with dotted line
-                    const actualDatasetIndex = throughputChart.data.datasets.findIndex(ds => ds.label === ifaceName);
-                    const color = actualDatasetIndex >= 0 ? throughputChart.data.datasets[actualDatasetIndex].borderColor : 'rgba(150, 150, 150, 0.6)';
-                    
-                    throughputChart.data.datasets.push({
-                        label: targetDatasetLabel,
-                        data: [],
-                        borderColor: color,
-                        backgroundColor: 'transparent',
-                        fill: false,
-                        borderWidth: 1,
-                        borderDash: [5, 5],
-                        pointRadius: 0,
-                        tension: 0
-                    });
-                    targetDatasetIndex = throughputChart.data.datasets.length - 1;
-                }
-                
-                // Backfill with nulls if needed
-                while (throughputChart.data.datasets[targetDatasetIndex].data.length < throughputChart.data.labels.length - 1) {
-                    throughputChart.data.datasets[targetDatasetIndex].data.push(null);
-                }
-                throughputChart.data.datasets[targetDatasetIndex].data.push(targetThroughput);
+            // With throughput samples only phases without load are taken from the data points
+            if (!fastThroughput || !data.throughput_by_interface) {
+                appendThroughputPoint(elapsedSeconds, throughputMbps, throughputByInterface, targetThroughputByInterface);
+                throughputValueDiv.textContent = throughputMbps.toFixed(1);
             }
-            
-            // Ensure all datasets have same length (fill with null for missing data)
-            for (let i = 0; i < throughputChart.data.datasets.length; i++) {
-                while (throughputChart.data.datasets[i].data.length < throughputChart.data.labels.length) {
-                    throughputChart.data.datasets[i].data.push(null);
-                }
-            }
-            
-            throughputChart.update();
-
-            // Update throughput display
-            throughputValueDiv.textContent = throughputMbps.toFixed(1);
 
             // Process events and add annotations
             const events = data.events || [];
@@ -1147,6 +1340,9 @@ document.addEventListener('DOMContentLoaded', () => {
                 throughput_mbps: throughputMbps,
                 throughput_by_interface: throughputByInterface,
                 target_throughput_by_interface: data.target_throughput_by_interface || {},
+                power_by_meter: data.power_by_meter || {},
+                quality: data.quality || 'ok',
+                repeated: data.repeated || false,
                 phase: phase,
                 events: events
             };
@@ -1163,6 +1359,20 @@ document.addEventListener('DOMContentLoaded', () => {
             }
         };
 
+        // Throughput sampled faster than the power readings replaces the
+        // data points' throughput in the chart
+        eventSource.addEventListener('throughput', function(event) {
+            const sample = JSON.parse(event.data);
+            if (!startTime) {
+                startTime = new Date(sample.timestamp).getTime();
+            }
+            fastThroughput = true;
+            const elapsedSeconds = (new Date(sample.timestamp).getTime() - startTime) / 1000;
+            appendThroughputPoint(elapsedSeconds, sample.throughput_mbps || 0,
+                sample.throughput_by_interface || {}, sample.target_throughput_by_interface || {});
+            throughputValueDiv.textContent = (sample.throughput_mbps || 0).toFixed(1);
+        });
+
         eventSource.addEventListener('done', function(e) {
             statusDiv.textContent = 'Status: Test Finished';
             startBtn.disabled = false;
@@ -1243,6 +1453,7 @@ document.addEventListener('DOMContentLoaded', () => {
                 collectedData = [];
                 startTime = null;
                 currentPhase = '';
+                fastThroughput = false;
 
                 // Store config for CSV export
                 lastTestConfig = getCurrentConfig();
@@ -1253,6 +1464,7 @@ document.addEventListener('DOMContentLoaded', () => {
                     progressSection.style.display = 'block';
                     // Calculate total duration and build event timeline
                     updateProgressTracking(lastTestConfig);
+                    applyRepetitionsToProgress(lastTestConfig);
                 }
 
                 connectSSE();
@@ -1335,12 +1547,25 @@ document.addEventListener('DOMContentLoaded', () => {
         });
         const interfaceList = Array.from(allInterfaces).sort();
 
-        // Build CSV header with dynamic interface columns
-        let csvHeader = "Timestamp,ElapsedSeconds,PowerMW,ThroughputTotalMbps,TargetThroughputTotalMbps";
+        // Collect the names of additional power meters
+        const allMeters = new Set();
+        collectedData.forEach(e => {
+            if (e.power_by_meter) {
+                Object.keys(e.power_by_meter).forEach(meter => allMeters.add(meter));
+            }
+        });
+        const meterList = Array.from(allMeters).sort();
+
+        // Build CSV header with dynamic meter and interface columns
+        let csvHeader = "Timestamp,ElapsedSeconds,PowerMW";
+        meterList.forEach(meter => {
+            csvHeader += `,Power_${meter}_MW`;
+        });
+        csvHeader += ",ThroughputTotalMbps,TargetThroughputTotalMbps";
         interfaceList.forEach(iface => {
             csvHeader += `,Throughput_${iface}_Mbps,Target_${iface}_Mbps`;
         });
-        csvHeader += ",Phase,Events";
+        csvHeader += ",Quality,Repeated,Phase,Events";
 
         // Build CSV rows
         const csvRows = collectedData.map(e => {
@@ -1349,7 +1574,13 @@ document.addEventListener('DOMContentLoaded', () => {
                 return sum + ((e.target_throughput_by_interface && e.target_throughput_by_interface[iface]) || 0);
             }, 0);
             
-            let row = `${e.timestamp},${e.elapsed_seconds},${e.power_mw},${e.throughput_mbps},${targetTotal}`;
+            let row = `${e.timestamp},${e.elapsed_seconds},${e.power_mw}`;
+            meterList.forEach(meter => {
+                // Leave the cell empty when the meter could not be read
+                const meterPower = e.power_by_meter && e.power_by_meter[meter];
+                row += `,${meterPower ?? ''}`;
+            });
+            row += `,${e.throughput_mbps},${targetTotal}`;
             interfaceList.forEach(iface => {
                 const ifaceThroughput = (e.throughput_by_interface && e.throughput_by_interface[iface]) || 0;
                 const ifaceTarget = (e.target_throughput_by_interface && e.target_throughput_by_interface[iface]) || 0;
@@ -1357,7 +1588,7 @@ document.addEventListener('DOMContentLoaded', () => {
             });
             // Format events as pipe-separated list and escape for CSV
             const eventsStr = (e.events || []).map(evt => `[${evt.type}] ${evt.message}`).join(' | ');
-            row += `,${e.phase},"${eventsStr.replace(/"/g, '""')}"`;
+            row += `,${e.quality || 'ok'},${e.repeated || false},${e.phase},"${eventsStr.replace(/"/g, '""')}"`;
             return row;
         }).join("\n");
 
@@ -1445,6 +1676,57 @@ document.addEventListener('DOMContentLoaded', () => {
         }
     }
 
+    // Runtime control of the running test: pause/resume, throughput and phase extension
+    const pauseLoadBtn = document.getElementById('pauseLoadBtn');
+    const controlInterface = document.getElementById('controlInterface');
+    const controlThroughput = document.getElementById('controlThroughput');
+    const setThroughputBtn = document.getElementById('setThroughputBtn');
+    const extendDuration = document.getElementById('extendDuration');
+    const extendPhaseBtn = document.getElementById('extendPhaseBtn');
+
+    async function postControl(url, fields) {
+        const formData = new FormData();
+        Object.entries(fields || {}).forEach(([key, value]) => formData.append(key, value));
+        const response = await fetch(url, { method: 'POST', body: formData });
+        if (!response.ok) {
+            alert('Control failed: ' + await response.text());
+        }
+        refreshControlState();
+        return response.ok;
+    }
+
+    // Updates the pause button and interface list from the server
+    async function refreshControlState() {
+        if (!pauseLoadBtn) return;
+        try {
+            const state = await (await fetch('/control')).json();
+            pauseLoadBtn.textContent = state.load_paused ? '▶ Resume Load' : '⏸ Pause Load';
+            pauseLoadBtn.dataset.paused = state.load_paused ? 'true' : 'false';
+            const selected = controlInterface.value;
+            controlInterface.innerHTML = (state.interfaces || []).map(name =>
+                `<option value="${name === 'default' ? '' : name}">${name} (${state.target_throughput_by_interface[name] || 'unlimited'} Mbps)</option>`
+            ).join('');
+            if (selected) controlInterface.value = selected;
+        } catch (err) {
+            console.error('Error reading control state:', err);
+        }
+    }
+
+    if (pauseLoadBtn) {
+        pauseLoadBtn.addEventListener('click', () => {
+            postControl(pauseLoadBtn.dataset.paused === 'true' ? '/control/resume' : '/control/pause');
+        });
+        setThroughputBtn.addEventListener('click', () => {
+            postControl('/control/throughput', { interface: controlInterface.value, throughput_mbps: controlThroughput.value || '0' });
+        });
+        extendPhaseBtn.addEventListener('click', () => {
+            postControl('/control/extend', { duration: extendDuration.value || '1m' });
+        });
+        setInterval(() => {
+            if (markerSection && markerSection.classList.contains('active')) refreshControlState();
+        }, 5000);
+    }
+
     // Build config object for saving
     function getCurrentConfig() {
         // Collect per-interface configs
@@ -1472,10 +1754,35 @@ document.addEventListener('DOMContentLoaded', () => {
             targetMAC: document.getElementById('target_mac')?.value || '',
             protocol: document.getElementById('protocol').value,
             packetSize: document.getElementById('packet_size').value,
-            interfaceConfigs: interfaceConfigs
+            interfaceConfigs: interfaceConfigs,
+            plan: getCurrentPlan(),
+            matrixRuns: getMatrixRunCount(),
+            repetitions: parseInt(document.getElementById('repetitions')?.value) || 1,
+            repetitionGap: document.getElementById('repetition_gap')?.value || '0s',
+            matrixCooldown: document.getElementById('matrix_cooldown')?.value || '0s'
         };
     }
 
+    // Returns the number of runs of the test matrix, or 0 if another test type is selected
+    function getMatrixRunCount() {
+        if (document.getElementById('test_type')?.value !== 'matrix') return 0;
+        const count = (id) => {
+            const values = (document.getElementById(id)?.value || '').split(',').filter(v => v.trim() !== '');
+            return Math.max(1, values.length);
+        };
+        return count('matrix_protocols') * count('matrix_packet_sizes') * count('matrix_port_counts');
+    }
+
+    // Returns the parsed test plan if the test plan type is selected
+    function getCurrentPlan() {
+        if (document.getElementById('test_type')?.value !== 'plan') return null;
+        try {
+            return JSON.parse(document.getElementById('test_plan').value);
+        } catch (err) {
+            return null;
+        }
+    }
+
     // Render history list
     async function renderHistoryList() {
         try {
@@ -1568,7 +1875,7 @@ document.addEventListener('DOMContentLoaded', () => {
             collectedData.push(dp);
             const label = dp.elapsed_seconds?.toFixed(0) || idx.toString();
             powerChart.data.labels.push(label);
-            powerChart.data.datasets[0].data.push(dp.power_mw);
+            powerChart.data.datasets[0].data.push(dp.quality === 'missing' ? null : dp.power_mw);
             throughputChart.data.labels.push(label);
             throughputChart.data.datasets[0].data.push(dp.throughput_mbps);
 
@@ -1670,12 +1977,25 @@ document.addEventListener('DOMContentLoaded', () => {
         });
         const interfaceList = Array.from(allInterfaces).sort();
 
-        // Build CSV header with dynamic interface columns
-        let csvHeader = "Timestamp,ElapsedSeconds,PowerMW,ThroughputTotalMbps,TargetThroughputTotalMbps";
+        // Collect the names of additional power meters
+        const allMeters = new Set();
+        test.data.forEach(e => {
+            if (e.power_by_meter) {
+                Object.keys(e.power_by_meter).forEach(meter => allMeters.add(meter));
+            }
+        });
+        const meterList = Array.from(allMeters).sort();
+
+        // Build CSV header with dynamic meter and interface columns
+        let csvHeader = "Timestamp,ElapsedSeconds,PowerMW";
+        meterList.forEach(meter => {
+            csvHeader += `,Power_${meter}_MW`;
+        });
+        csvHeader += ",ThroughputTotalMbps,TargetThroughputTotalMbps";
         interfaceList.forEach(iface => {
             csvHeader += `,Throughput_${iface}_Mbps,Target_${iface}_Mbps`;
         });
-        csvHeader += ",Phase,Events";
+        csvHeader += ",Quality,Repeated,Phase,Events";
 
         // Build CSV rows
         const csvRows = test.data.map(e => {
@@ -1684,7 +2004,13 @@ document.addEventListener('DOMContentLoaded', () => {
                 return sum + ((e.target_throughput_by_interface && e.target_throughput_by_interface[iface]) || 0);
             }, 0);
             
-            let row = `${e.timestamp},${e.elapsed_seconds},${e.power_mw},${e.throughput_mbps},${targetTotal}`;
+            let row = `${e.timestamp},${e.elapsed_seconds},${e.power_mw}`;
+            meterList.forEach(meter => {
+                // Leave the cell empty when the meter could not be read
+                const meterPower = e.power_by_meter && e.power_by_meter[meter];
+                row += `,${meterPower ?? ''}`;
+            });
+            row += `,${e.throughput_mbps},${targetTotal}`;
             interfaceList.forEach(iface => {
                 const ifaceThroughput = (e.throughput_by_interface && e.throughput_by_interface[iface]) || 0;
                 const ifaceTarget = (e.target_throughput_by_interface && e.target_throughput_by_interface[iface]) || 0;
@@ -1692,7 +2018,7 @@ document.addEventListener('DOMContentLoaded', () => {
             });
             // Format events as pipe-separated list and escape for CSV
             const eventsStr = (e.events || []).map(evt => `[${evt.type}] ${evt.message}`).join(' | ');
-            row += `,${e.phase},"${eventsStr.replace(/"/g, '""')}"`;
+            row += `,${e.quality || 'ok'},${e.repeated || false},${e.phase},"${eventsStr.replace(/"/g, '""')}"`;
             return row;
         }).join("\n");
 
@@ -1759,6 +2085,99 @@ document.addEventListener('DOMContentLoaded', () => {
 
     // Initial render of history list
     renderHistoryList();
+
+    // Test queue
+    const queueBtn = document.getElementById('queueBtn');
+    const queueListDiv = document.getElementById('queueList');
+
+    // Render the server-side test queue
+    async function renderQueueList() {
+        try {
+            const response = await fetch('/queue');
+            if (!response.ok) return;
+            const entries = (await response.json()) || [];
+            const pending = entries.filter(e => e.status === 'queued');
+            if (entries.length === 0) {
+                queueListDiv.innerHTML = '<em>Queue is empty</em>';
+                return;
+            }
+
+            // Pending and running entries first, then the ten most recently finished
+            const finished = entries.filter(e => e.status !== 'queued' && e.status !== 'running')
+                .sort((a, b) => new Date(b.finished_at) - new Date(a.finished_at))
+                .slice(0, 10);
+            const shown = entries.filter(e => e.status === 'queued' || e.status === 'running').concat(finished);
+
+            queueListDiv.innerHTML = shown.map(entry => {
+                const index = pending.indexOf(entry);
+                const when = entry.start_at ? new Date(entry.start_at).toLocaleString() : 'as soon as possible';
+                const details = [
+                    entry.status,
+                    entry.status === 'queued' ? `starts ${when}` : '',
+                    entry.cron ? `cron: ${entry.cron}` : '',
+                    entry.run_count ? `${entry.run_count} run(s)` : '',
+                    entry.error ? `error: ${entry.error}` : ''
+                ].filter(Boolean).join(' | ');
+                const actions = entry.status === 'queued' || entry.status === 'running' ? `
+                    ${index > 0 ? `<button class="btn-small queue-move" data-position="${index}" title="Move up">⬆️</button>` : ''}
+                    ${index >= 0 && index < pending.length - 1 ? `<button class="btn-small queue-move" data-position="${index + 2}" title="Move down">⬇️</button>` : ''}
+                    <button class="btn-small btn-danger queue-cancel" title="Cancel">✖</button>` : '';
+                return `
+                    <div class="history-item" data-id="${entry.id}">
+                        <div class="history-info">
+                            <div class="title">${entry.test_name}</div>
+                            <div class="details">${details}</div>
+                        </div>
+                        <div class="history-actions">${actions}</div>
+                    </div>
+                `;
+            }).join('');
+
+            queueListDiv.querySelectorAll('.history-item').forEach(item => {
+                const id = item.dataset.id;
+                item.querySelectorAll('.queue-move').forEach(btn => {
+                    btn.addEventListener('click', async () => {
+                        const formData = new FormData();
+                        formData.append('position', btn.dataset.position);
+                        await fetch(`/queue/move/${id}`, { method: 'POST', body: formData });
+                        renderQueueList();
+                    });
+                });
+                item.querySelector('.queue-cancel')?.addEventListener('click', async () => {
+                    if (confirm('Cancel this queued test?')) {
+                        await fetch(`/queue/cancel/${id}`, { method: 'POST' });
+                        renderQueueList();
+                    }
+                });
+            });
+        } catch (err) {
+            console.error('Error loading queue:', err);
+        }
+    }
+
+    // Add the current configuration to the queue
+    if (queueBtn) {
+        queueBtn.addEventListener('click', async () => {
+            saveConfigToStorage();
+            try {
+                const response = await fetch('/queue', {
+                    method: 'POST',
+                    body: new FormData(form)
+                });
+                if (!response.ok) {
+                    alert('Error queueing test: ' + await response.text());
+                    return;
+                }
+                renderQueueList();
+            } catch (err) {
+                console.error(err);
+                alert('Error queueing test');
+            }
+        });
+    }
+
+    renderQueueList();
+    setInterval(renderQueueList, 10000);
 });
 
 // Global function to select a device from discovery results